@@ -1,7 +1,17 @@
 package internal
 
+const (
+	// FormatManifests writes one file per K8s resource
+	FormatManifests = "manifests"
+	// FormatKustomize additionally writes a kustomization.yaml listing all resources
+	FormatKustomize = "kustomize"
+	// FormatHelm writes a Helm chart
+	FormatHelm = "helm"
+)
+
 type Config struct {
 	OutputDir   string   `json:"outputDir"`
+	Format      string   `json:"format"`
 	Env         string   `json:"env"`
 	Ref         string   `json:"ref"`
 	ConfigFiles []string `json:"configFiles"`
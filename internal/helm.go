@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vshn/k8ify/pkg/converter"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// HelmReleaseRef is used as `ref` when generating a Helm chart. It survives sanitizing unchanged and is replaced by
+// the name of the Helm release in the generated templates.
+const HelmReleaseRef = "k8ifyhelmrelease"
+
+type helmChart struct {
+	APIVersion  string `json:"apiVersion"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	Version     string `json:"version"`
+}
+
+// helmEscaper makes Helm render braces already contained in the manifests (e.g. in environment variables or files)
+// literally instead of interpreting them as template expressions
+var helmEscaper = strings.NewReplacer("{{", `{{"{{"}}`, "}}", `{{"}}"}}`)
+
+// helmTemplater collects the values of a chart while replacing literals in the manifests by references to them
+type helmTemplater struct {
+	values map[string]interface{}
+	// placeholders maps the placeholder strings put into the manifests to the template expressions replacing them
+	placeholders map[string]string
+}
+
+// WriteHelmChart writes the objects as a Helm chart: The manifests are written to the `templates` directory, with
+// image tags, replicas, resource requests and limits and ingress hosts taken from values.yaml and the `ref` suffix replaced by
+// the name of the Helm release.
+func WriteHelmChart(outputDir string, chartName string, chartVersion string, objects converter.Objects) error {
+	templatesDir := filepath.Join(outputDir, "templates")
+	err := prepareOutputDir(outputDir)
+	if err != nil {
+		return err
+	}
+	err = os.RemoveAll(templatesDir)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(templatesDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	templater := helmTemplater{
+		values:       make(map[string]interface{}),
+		placeholders: make(map[string]string),
+	}
+	err = writeObjects(objects, func(obj runtime.Object, fileName string) error {
+		template, err := templater.template(obj)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(templatesDir, fileName), template, 0644)
+	})
+	if err != nil {
+		return err
+	}
+
+	values, err := yaml.Marshal(templater.values)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(outputDir, "values.yaml"), values, 0644)
+	if err != nil {
+		return err
+	}
+
+	chart, err := yaml.Marshal(helmChart{
+		APIVersion:  "v2",
+		Name:        chartName,
+		Description: "Generated by k8ify",
+		Type:        "application",
+		Version:     chartVersion,
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputDir, "Chart.yaml"), chart, 0644)
+}
+
+func (h *helmTemplater) template(obj runtime.Object) ([]byte, error) {
+	var content map[string]interface{}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		content = u.Object
+	} else {
+		var err error
+		content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+	}
+	u := unstructured.Unstructured{Object: content}
+	serviceKey := helmValuesKey(u.GetLabels()["k8ify.service"])
+
+	switch u.GetKind() {
+	case "Deployment", "StatefulSet", "DaemonSet":
+		if replicas, found, _ := unstructured.NestedFieldNoCopy(content, "spec", "replicas"); found && replicas != nil {
+			h.setValue(replicas, serviceKey, "replicas")
+			content["spec"].(map[string]interface{})["replicas"] = h.placeholder(fmt.Sprintf("{{ .Values.%s.replicas }}", serviceKey))
+		}
+		containers, _, _ := unstructured.NestedSlice(content, "spec", "template", "spec", "containers")
+		for _, c := range containers {
+			h.templateContainer(c.(map[string]interface{}))
+		}
+		err := unstructured.SetNestedSlice(content, containers, "spec", "template", "spec", "containers")
+		if err != nil {
+			return nil, err
+		}
+	case "Ingress":
+		h.templateIngress(content, serviceKey)
+	}
+
+	data, err := yaml.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	template := helmEscaper.Replace(string(data))
+	for placeholder, expression := range h.placeholders {
+		template = strings.ReplaceAll(template, placeholder, expression)
+	}
+	template = strings.ReplaceAll(template, HelmReleaseRef, "{{ .Release.Name }}")
+	return []byte(template), nil
+}
+
+func (h *helmTemplater) templateContainer(container map[string]interface{}) {
+	name, _ := container["name"].(string)
+	containerKey := helmValuesKey(strings.TrimSuffix(name, "-"+HelmReleaseRef))
+
+	if image, ok := container["image"].(string); ok {
+		repository, tag := splitImage(image)
+		h.setValue(repository, containerKey, "image", "repository")
+		h.setValue(tag, containerKey, "image", "tag")
+		container["image"] = h.placeholder(fmt.Sprintf(`"{{ .Values.%[1]s.image.repository }}{{ with .Values.%[1]s.image.tag }}:{{ . }}{{ end }}"`, containerKey))
+	}
+
+	for _, kind := range []string{"requests", "limits"} {
+		resources, _, _ := unstructured.NestedMap(container, "resources", kind)
+		for resourceName, quantity := range resources {
+			h.setValue(quantity, containerKey, "resources", kind, resourceName)
+			resources[resourceName] = h.placeholder(fmt.Sprintf("{{ .Values.%s.resources.%s.%s }}", containerKey, kind, resourceName))
+		}
+		if len(resources) > 0 {
+			_ = unstructured.SetNestedMap(container, resources, "resources", kind)
+		}
+	}
+}
+
+func (h *helmTemplater) templateIngress(content map[string]interface{}, serviceKey string) {
+	hosts := []interface{}{}
+	hostIndex := func(host string) int {
+		for i, h := range hosts {
+			if h == host {
+				return i
+			}
+		}
+		hosts = append(hosts, host)
+		return len(hosts) - 1
+	}
+	hostPlaceholder := func(host string) string {
+		return h.placeholder(fmt.Sprintf("{{ index .Values.%s.ingress.hosts %d }}", serviceKey, hostIndex(host)))
+	}
+
+	rules, _, _ := unstructured.NestedSlice(content, "spec", "rules")
+	for _, r := range rules {
+		rule := r.(map[string]interface{})
+		if host, ok := rule["host"].(string); ok {
+			rule["host"] = hostPlaceholder(host)
+		}
+	}
+	tlss, _, _ := unstructured.NestedSlice(content, "spec", "tls")
+	for _, t := range tlss {
+		tls := t.(map[string]interface{})
+		tlsHosts, _ := tls["hosts"].([]interface{})
+		for i, host := range tlsHosts {
+			tlsHosts[i] = hostPlaceholder(host.(string))
+		}
+	}
+	_ = unstructured.SetNestedSlice(content, rules, "spec", "rules")
+	if len(tlss) > 0 {
+		_ = unstructured.SetNestedSlice(content, tlss, "spec", "tls")
+	}
+	if len(hosts) > 0 {
+		h.setValue(hosts, serviceKey, "ingress", "hosts")
+	}
+}
+
+// placeholder registers a template expression and returns the placeholder to put into the manifest in its place
+func (h *helmTemplater) placeholder(expression string) string {
+	placeholder := fmt.Sprintf("k8ifyhelmvalue%dx", len(h.placeholders))
+	h.placeholders[placeholder] = expression
+	return placeholder
+}
+
+func (h *helmTemplater) setValue(value interface{}, path ...string) {
+	err := unstructured.SetNestedField(h.values, runtime.DeepCopyJSONValue(value), path...)
+	if err != nil {
+		logrus.Warnf("Could not set Helm value %s: %s", strings.Join(path, "."), err)
+	}
+}
+
+// splitImage splits an image reference into repository and tag. The tag is empty if the image has none.
+func splitImage(image string) (string, string) {
+	if strings.Contains(image, "@") {
+		// images referenced by digest are not split up
+		return image, ""
+	}
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		// the colon belongs to a registry port, not to a tag
+		return image, ""
+	}
+	return image[:i], image[i+1:]
+}
+
+// helmValuesKey converts a name like "my-service" into a key that can be used in Helm templates, like "myService"
+func helmValuesKey(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
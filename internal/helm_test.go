@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	core "k8s.io/api/core/v1"
+)
+
+func TestSplitImage(t *testing.T) {
+	tests := map[string][2]string{
+		"nginx":                                   {"nginx", ""},
+		"docker.io/library/nginx:1.27":            {"docker.io/library/nginx", "1.27"},
+		"registry.example.com:5000/myapp/php":     {"registry.example.com:5000/myapp/php", ""},
+		"registry.example.com:5000/myapp/php:8.3": {"registry.example.com:5000/myapp/php", "8.3"},
+		"nginx@sha256:0123456789abcdef":           {"nginx@sha256:0123456789abcdef", ""},
+	}
+	for image, expected := range tests {
+		repository, tag := splitImage(image)
+		assert.Equal(t, expected[0], repository, image)
+		assert.Equal(t, expected[1], tag, image)
+	}
+}
+
+func TestHelmValuesKey(t *testing.T) {
+	assert.Equal(t, "nginx", helmValuesKey("nginx"))
+	assert.Equal(t, "phpFpm", helmValuesKey("php-fpm"))
+	assert.Equal(t, "myApp2", helmValuesKey("my_app.2"))
+}
+
+func TestHelmTemplate(t *testing.T) {
+	templater := helmTemplater{
+		values:       make(map[string]interface{}),
+		placeholders: make(map[string]string),
+	}
+	secret := core.Secret{}
+	secret.APIVersion = "v1"
+	secret.Kind = "Secret"
+	secret.Name = "web-" + HelmReleaseRef + "-env"
+	secret.Annotations = map[string]string{"example.com/template": "{{name}}"}
+	secret.StringData = map[string]string{"GREETING": "Hello {{ name }}"}
+
+	template, err := templater.template(&secret)
+	assert.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  annotations:
+    example.com/template: '{{"{{"}}name{{"}}"}}'
+  name: web-{{ .Release.Name }}-env
+stringData:
+  GREETING: Hello {{"{{"}} name {{"}}"}}
+`, string(template))
+	assert.Empty(t, templater.values)
+}
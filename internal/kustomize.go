@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"os"
+	"sort"
+
+	"github.com/vshn/k8ify/pkg/converter"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// Kustomization is the subset of a kustomization.yaml that k8ify generates
+type Kustomization struct {
+	APIVersion   string            `json:"apiVersion"`
+	Kind         string            `json:"kind"`
+	Namespace    string            `json:"namespace,omitempty"`
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+	Resources    []string          `json:"resources"`
+}
+
+// WriteKustomization writes the manifests just like WriteManifests does, and additionally writes a kustomization.yaml
+// listing all of them, so that the output directory can be used as a kustomize base.
+func WriteKustomization(outputDir string, objects converter.Objects, namespace string, commonLabels map[string]string) error {
+	resources := []string{}
+	err := prepareOutputDir(outputDir)
+	if err != nil {
+		return err
+	}
+	err = writeObjects(objects, func(obj runtime.Object, fileName string) error {
+		resources = append(resources, fileName)
+		return writeManifest(obj, outputDir+"/"+fileName)
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(resources)
+
+	kustomization := Kustomization{
+		APIVersion:   "kustomize.config.k8s.io/v1beta1",
+		Kind:         "Kustomization",
+		Namespace:    namespace,
+		CommonLabels: commonLabels,
+		Resources:    resources,
+	}
+	data, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputDir+"/kustomization.yaml", data, 0644)
+}
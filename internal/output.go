@@ -1,7 +1,9 @@
 package internal
 
 import (
+	"io"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -10,6 +12,9 @@ import (
 	"k8s.io/cli-runtime/pkg/printers"
 )
 
+// StdoutOutput is the output "directory" that makes k8ify write all manifests to stdout instead of into files
+const StdoutOutput = "-"
+
 func prepareOutputDir(outputDir string) error {
 	err := os.MkdirAll(outputDir, os.ModePerm)
 	if err != nil {
@@ -56,16 +61,60 @@ func WriteManifests(outputDir string, objects converter.Objects) error {
 		os.Exit(1)
 	}
 
+	return writeObjects(objects, func(obj runtime.Object, fileName string) error {
+		return writeManifest(obj, outputDir+"/"+fileName)
+	})
+}
+
+// manifest is a single object along with the name of the file it would be written to
+type manifest struct {
+	kind     string
+	fileName string
+	object   runtime.Object
+}
+
+// WriteManifestStream writes all objects as a single multi-document YAML stream. The objects are sorted by kind and
+// name, so that the output does not depend on the order of the Compose services.
+func WriteManifestStream(w io.Writer, objects converter.Objects) error {
+	manifests := []manifest{}
+	err := writeObjects(objects, func(obj runtime.Object, fileName string) error {
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		manifests = append(manifests, manifest{kind: kind, fileName: fileName, object: obj})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(manifests, func(i, j int) bool {
+		if manifests[i].kind != manifests[j].kind {
+			return manifests[i].kind < manifests[j].kind
+		}
+		return manifests[i].fileName < manifests[j].fileName
+	})
+
+	yp := printers.YAMLPrinter{}
+	for _, m := range manifests {
+		err := yp.PrintObj(m.object, w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeObjects hands every object along with its file name to `write`
+func writeObjects(objects converter.Objects, write func(obj runtime.Object, fileName string) error) error {
 	for _, ciliumNetworkPolicy := range objects.CiliumNetworkPolicies {
 		name := ciliumNetworkPolicy.GetName()
-		err := writeManifest(&ciliumNetworkPolicy, outputDir+"/"+name+"-ciliumnetworkpolicy.yaml")
+		err := write(&ciliumNetworkPolicy, name+"-ciliumnetworkpolicy.yaml")
 		if err != nil {
 			return err
 		}
 	}
 
 	for _, deployment := range objects.Deployments {
-		err := writeManifest(&deployment, outputDir+"/"+deployment.Name+"-deployment.yaml")
+		err := write(&deployment, deployment.Name+"-deployment.yaml")
 		if err != nil {
 			return err
 		}
@@ -73,15 +122,39 @@ func WriteManifests(outputDir string, objects converter.Objects) error {
 	logrus.Infof("wrote %d deployments\n", len(objects.Deployments))
 
 	for _, statefulset := range objects.StatefulSets {
-		err := writeManifest(&statefulset, outputDir+"/"+statefulset.Name+"-statefulset.yaml")
+		err := write(&statefulset, statefulset.Name+"-statefulset.yaml")
 		if err != nil {
 			return err
 		}
 	}
 	logrus.Infof("wrote %d statefulsets\n", len(objects.StatefulSets))
 
+	for _, daemonSet := range objects.DaemonSets {
+		err := write(&daemonSet, daemonSet.Name+"-daemonset.yaml")
+		if err != nil {
+			return err
+		}
+	}
+	logrus.Infof("wrote %d daemonsets\n", len(objects.DaemonSets))
+
+	for _, job := range objects.Jobs {
+		err := write(&job, job.Name+"-job.yaml")
+		if err != nil {
+			return err
+		}
+	}
+	logrus.Infof("wrote %d jobs\n", len(objects.Jobs))
+
+	for _, cronJob := range objects.CronJobs {
+		err := write(&cronJob, cronJob.Name+"-cronjob.yaml")
+		if err != nil {
+			return err
+		}
+	}
+	logrus.Infof("wrote %d cronjobs\n", len(objects.CronJobs))
+
 	for _, service := range objects.Services {
-		err := writeManifest(&service, outputDir+"/"+service.Name+"-service.yaml")
+		err := write(&service, service.Name+"-service.yaml")
 		if err != nil {
 			return err
 		}
@@ -89,7 +162,7 @@ func WriteManifests(outputDir string, objects converter.Objects) error {
 	logrus.Infof("wrote %d services\n", len(objects.Services))
 
 	for _, serviceMonitor := range objects.ServiceMonitors {
-		err := writeManifest(&serviceMonitor, outputDir+"/"+serviceMonitor.GetName()+"-servicemonitor.yaml")
+		err := write(&serviceMonitor, serviceMonitor.GetName()+"-servicemonitor.yaml")
 		if err != nil {
 			return err
 		}
@@ -97,43 +170,91 @@ func WriteManifests(outputDir string, objects converter.Objects) error {
 	logrus.Infof("wrote %d servicemonitors\n", len(objects.ServiceMonitors))
 
 	for _, persistentVolumeClaim := range objects.PersistentVolumeClaims {
-		err := writeManifest(&persistentVolumeClaim, outputDir+"/"+persistentVolumeClaim.Name+"-persistentvolumeclaim.yaml")
+		err := write(&persistentVolumeClaim, persistentVolumeClaim.Name+"-persistentvolumeclaim.yaml")
 		if err != nil {
 			return err
 		}
 	}
 	logrus.Infof("wrote %d persistentVolumeClaims\n", len(objects.PersistentVolumeClaims))
 
+	for _, backupSchedule := range objects.BackupSchedules {
+		err := write(&backupSchedule, backupSchedule.GetName()+"-schedule.yaml")
+		if err != nil {
+			return err
+		}
+	}
+	logrus.Infof("wrote %d backupSchedules\n", len(objects.BackupSchedules))
+
+	for _, certificate := range objects.Certificates {
+		err := write(&certificate, certificate.GetName()+"-certificate.yaml")
+		if err != nil {
+			return err
+		}
+	}
+	logrus.Infof("wrote %d certificates\n", len(objects.Certificates))
+
 	for _, secret := range objects.Secrets {
 		manifestName := secret.Name
 		if !strings.HasSuffix(manifestName, "-secret") {
 			manifestName += "-secret"
 		}
-		err := writeManifest(&secret, outputDir+"/"+manifestName+".yaml")
+		err := write(&secret, manifestName+".yaml")
 		if err != nil {
 			return err
 		}
 	}
 	logrus.Infof("wrote %d secrets\n", len(objects.Secrets))
 
+	for _, configMap := range objects.ConfigMaps {
+		err := write(&configMap, configMap.Name+"-configmap.yaml")
+		if err != nil {
+			return err
+		}
+	}
+	logrus.Infof("wrote %d configMaps\n", len(objects.ConfigMaps))
+
 	for _, ingress := range objects.Ingresses {
-		err := writeManifest(&ingress, outputDir+"/"+ingress.Name+"-ingress.yaml")
+		err := write(&ingress, ingress.Name+"-ingress.yaml")
 		if err != nil {
 			return err
 		}
 	}
 	logrus.Infof("wrote %d ingresses\n", len(objects.Ingresses))
 
+	for _, route := range objects.Routes {
+		err := write(&route, route.GetName()+"-"+strings.ToLower(route.GetKind())+".yaml")
+		if err != nil {
+			return err
+		}
+	}
+	logrus.Infof("wrote %d routes\n", len(objects.Routes))
+
+	for _, networkPolicy := range objects.NetworkPolicies {
+		err := write(&networkPolicy, networkPolicy.Name+"-networkpolicy.yaml")
+		if err != nil {
+			return err
+		}
+	}
+	logrus.Infof("wrote %d networkPolicies\n", len(objects.NetworkPolicies))
+
 	for _, podDisruptionBudget := range objects.PodDisruptionBudgets {
-		err := writeManifest(&podDisruptionBudget, outputDir+"/"+podDisruptionBudget.Name+"-poddisruptionbudget.yaml")
+		err := write(&podDisruptionBudget, podDisruptionBudget.Name+"-poddisruptionbudget.yaml")
 		if err != nil {
 			return err
 		}
 	}
 	logrus.Infof("wrote %d podDisruptionBudgets\n", len(objects.PodDisruptionBudgets))
 
+	for _, horizontalPodAutoscaler := range objects.HorizontalPodAutoscalers {
+		err := write(&horizontalPodAutoscaler, horizontalPodAutoscaler.Name+"-horizontalpodautoscaler.yaml")
+		if err != nil {
+			return err
+		}
+	}
+	logrus.Infof("wrote %d horizontalPodAutoscalers\n", len(objects.HorizontalPodAutoscalers))
+
 	for _, other := range objects.Others {
-		err := writeManifest(&other, outputDir+"/"+other.GetName()+"-"+strings.ToLower(other.GetObjectKind().GroupVersionKind().Kind)+".yaml")
+		err := write(&other, other.GetName()+"-"+strings.ToLower(other.GetObjectKind().GroupVersionKind().Kind)+".yaml")
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	assertions "github.com/stretchr/testify/assert"
+	"github.com/vshn/k8ify/pkg/converter"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWriteManifestStream(t *testing.T) {
+	assert := assertions.New(t)
+	typeMeta := func(kind string, apiVersion string) metav1.TypeMeta {
+		return metav1.TypeMeta{Kind: kind, APIVersion: apiVersion}
+	}
+	objects := converter.Objects{
+		Deployments: []apps.Deployment{
+			{TypeMeta: typeMeta("Deployment", "apps/v1"), ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+			{TypeMeta: typeMeta("Deployment", "apps/v1"), ObjectMeta: metav1.ObjectMeta{Name: "api"}},
+		},
+		Services: []core.Service{
+			{TypeMeta: typeMeta("Service", "v1"), ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+		},
+		Secrets: []core.Secret{
+			{TypeMeta: typeMeta("Secret", "v1"), ObjectMeta: metav1.ObjectMeta{Name: "web-env"}},
+		},
+	}
+
+	var out bytes.Buffer
+	err := WriteManifestStream(&out, objects)
+	assert.NoError(err)
+
+	documents := strings.Split(out.String(), "---\n")
+	assert.Len(documents, 4, "every object should be a separate YAML document")
+
+	expectedOrder := []string{"kind: Deployment\nmetadata:\n  name: api", "kind: Deployment\nmetadata:\n  name: web", "kind: Secret\nmetadata:\n  name: web-env", "kind: Service\nmetadata:\n  name: web"}
+	for i, expected := range expectedOrder {
+		assert.Contains(documents[i], expected, "document %d is not in the expected order", i)
+	}
+}
@@ -1,7 +1,10 @@
 package internal
 
 import (
+	"fmt"
 	"os"
+	"reflect"
+	"sort"
 
 	"github.com/vshn/k8ify/pkg/util"
 
@@ -42,12 +45,41 @@ func ComposeServicePrecheck(inputs *ir.Inputs) {
 				os.Exit(1)
 			}
 		}
+		for _, s := range append([]*ir.Service{&service.Service}, service.GetParts()...) {
+			// CHECK: Port ranges must be valid and must not generate too many ports
+			if err := s.CheckPortRanges(inputs.TargetCfg.MaxPorts()); err != nil {
+				logrus.Errorf("Service '%s': %s", s.Name, err.Error())
+				os.Exit(1)
+			}
+		}
 		environmentValues := service.AsCompose().Environment
 		for key, value := range environmentValues {
 			if value == nil {
 				logrus.Warnf("Service '%s' has environment variable '%s' with value nil. There may be a problem with your compose file(s). Please use empty string \"\" values instead.", service.Name, key)
 			}
 		}
+		autoscaleConfig, err := ir.AutoscaleConfigPointer(service.Labels())
+		if err != nil {
+			logrus.Errorf("Service '%s': %s", service.Name, err.Error())
+			os.Exit(1)
+		}
+		jobConfig, err := ir.JobConfigPointer(service.Labels())
+		if err != nil {
+			logrus.Errorf("Service '%s': %s", service.Name, err.Error())
+			os.Exit(1)
+		}
+		if jobConfig != nil && autoscaleConfig != nil {
+			logrus.Errorf("Service '%s': A %s can't be autoscaled, remove the k8ify.autoscale labels", service.Name, jobConfig.Kind)
+			os.Exit(1)
+		}
+		if service.IsGlobal() && autoscaleConfig != nil {
+			logrus.Errorf("Service '%s': A service with `deploy.mode: global` runs on every node and can't be autoscaled, remove the k8ify.autoscale labels", service.Name)
+			os.Exit(1)
+		}
+		if service.IsGlobal() && jobConfig != nil {
+			logrus.Errorf("Service '%s': A %s can't have `deploy.mode: global`", service.Name, jobConfig.Kind)
+			os.Exit(1)
+		}
 		serviceMonitorConfig := ir.ServiceMonitorConfigPointer(service.Labels())
 		if serviceMonitorConfig != nil {
 			_, basicAuthError := ir.ServiceMonitorBasicAuthConfigPointer(service.Labels())
@@ -97,17 +129,60 @@ func VolumesPrecheck(inputs *ir.Inputs) {
 				os.Exit(1)
 			}
 
+			references[volumeName] = append(references[volumeName], service.Name)
+
+			// External volumes already exist, k8ify just mounts them
+			if volume.IsExternal() {
+				continue
+			}
+
 			// CHECK: Service is singleton but volume is not
 			if service.IsSingleton() != volume.IsSingleton() {
 				logrus.Errorf("Service %q, Volume %q: `k8ify.singleton` labels must be identical", service.Name, volumeName)
 				os.Exit(1)
 			}
 
-			references[volumeName] = append(references[volumeName], service.Name)
+			// CHECK: Jobs can't use non-shared volumes, those are only supported via StatefulSets
+			if jobConfig, _ := ir.JobConfigPointer(service.Labels()); jobConfig != nil && !volume.IsShared() {
+				logrus.Errorf("Service %q is a %s, but Volume %q is not marked as shared (via the `k8ify.shared` label on the volume). Jobs can only use shared volumes.", service.Name, jobConfig.Kind, volumeName)
+				os.Exit(1)
+			}
+
+			// CHECK: DaemonSets can't use non-shared volumes, the pods on all nodes would have to share them
+			if service.IsGlobal() && !volume.IsShared() {
+				logrus.Errorf("Service %q has `deploy.mode: global`, but Volume %q is not marked as shared (via the `k8ify.shared` label on the volume). DaemonSets can only use shared volumes.", service.Name, volumeName)
+				os.Exit(1)
+			}
 		}
 	}
 
 	for name, volume := range inputs.Volumes {
+		// External volumes already exist, the labels controlling the creation of volumes don't apply to them
+		if volume.IsExternal() {
+			continue
+		}
+
+		// CHECK: Backups need a place to be stored at
+		backupConfig, err := ir.BackupConfigPointer(volume.Labels())
+		if err != nil {
+			logrus.Errorf("Volume %q: %s", name, err.Error())
+			os.Exit(1)
+		}
+		if backupConfig != nil && (inputs.TargetCfg.BackupEndpoint() == "" || inputs.TargetCfg.BackupBucket() == "" || inputs.TargetCfg.BackupRepositoryPassword() == "") {
+			logrus.Errorf("Volume %q is supposed to be backed up, but the backup storage is not configured. Set 'x-targetCfg.backup.endpoint', 'x-targetCfg.backup.bucket' and 'x-targetCfg.backup.repositoryPassword'.", name)
+			os.Exit(1)
+		}
+
+		// CHECK: Volumes can only be cloned from a PersistentVolumeClaim or VolumeSnapshot
+		cloneConfig, err := ir.CloneConfigPointer(volume.Labels())
+		if err != nil {
+			logrus.Errorf("Volume %q: %s", name, err.Error())
+			os.Exit(1)
+		}
+		if cloneConfig != nil && volume.IsSingleton() {
+			logrus.Warnf("Volume %q is a singleton and shared by all refs, ignoring k8ify.cloneFromRef", name)
+		}
+
 		// CHECK: No size defined
 		if volume.SizeIsMissing() {
 			logrus.Warnf("Volume %q has no size specified!", name)
@@ -130,10 +205,182 @@ func VolumesPrecheck(inputs *ir.Inputs) {
 func DomainLengthPrecheck(inputs *ir.Inputs) {
 	maxExposeLength := inputs.TargetCfg.MaxExposeLength()
 	for _, service := range inputs.Services {
-		for _, domain := range util.SubConfig(service.Labels(), "k8ify.expose", "default") {
-			if !inputs.TargetCfg.IsSubdomainOfAppsDomain(domain) && len(domain) > maxExposeLength {
-				logrus.Errorf("Service '%s' is supposed to be exposed on domain '%s' which is longer than %d characters. This likely won't work due to certificate common name length restrictions.", service.Name, domain, maxExposeLength)
-				logrus.Errorf("To fix this you can use the cluster's appsDomain wildcard certificate (compose file option 'x-targetCfg.appsDomain') or adjust this check ('x-targetCfg.maxExposeLength').")
+		for _, s := range append([]*ir.Service{&service.Service}, service.GetParts()...) {
+			ingressConfigs, _ := s.GetIngressConfigs()
+			for _, ingressConfig := range ingressConfigs {
+				for _, domain := range ingressConfig.Hosts {
+					if !inputs.TargetCfg.IsSubdomainOfAppsDomain(domain) && len(domain) > maxExposeLength {
+						logrus.Errorf("Service '%s' is supposed to be exposed on domain '%s' which is longer than %d characters. This likely won't work due to certificate common name length restrictions.", s.Name, domain, maxExposeLength)
+						logrus.Errorf("To fix this you can use the cluster's appsDomain wildcard certificate (compose file option 'x-targetCfg.appsDomain') or adjust this check ('x-targetCfg.maxExposeLength').")
+						os.Exit(1)
+					}
+				}
+			}
+		}
+	}
+}
+
+// IngressPrecheck makes sure the ports exposed via Ingress are configured properly and that no two of them are exposed
+// on the same host and path, since only one of them would receive any traffic.
+func IngressPrecheck(inputs *ir.Inputs) {
+	names := make([]string, 0, len(inputs.Services))
+	for name := range inputs.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	exposedBy := make(map[string]string)
+	for _, name := range names {
+		service := inputs.Services[name]
+		for _, s := range append([]*ir.Service{&service.Service}, service.GetParts()...) {
+			ingressConfigs, err := s.GetIngressConfigs()
+			if err != nil {
+				logrus.Errorf("Service '%s': %s", s.Name, err.Error())
+				os.Exit(1)
+			}
+			for _, ingressConfig := range ingressConfigs {
+				port := fmt.Sprintf("port %d of service '%s'", ingressConfig.Port, s.Name)
+				for _, host := range ingressConfig.Hosts {
+					location := host + ingressConfig.Path
+					if other, ok := exposedBy[location]; ok {
+						logrus.Errorf("Both %s and %s are exposed on '%s'. Use different hosts or paths (via 'k8ify.expose.$port.path').", other, port, location)
+						os.Exit(1)
+					}
+					exposedBy[location] = port
+				}
+			}
+		}
+	}
+}
+
+// ExposeSchemePrecheck makes sure the target cluster configuration provides everything the expose scheme needs
+func ExposeSchemePrecheck(inputs *ir.Inputs) {
+	scheme := inputs.TargetCfg.ExposeScheme()
+	switch scheme {
+	case ir.ExposeSchemeIngress, ir.ExposeSchemeOpenShiftRoute:
+	case ir.ExposeSchemeGatewayAPI:
+		if inputs.TargetCfg.GatewayName() == "" {
+			logrus.Errorf("'x-targetCfg.exposeScheme' is '%s', but no Gateway is configured. Set 'x-targetCfg.gateway.name'.", scheme)
+			os.Exit(1)
+		}
+	default:
+		logrus.Errorf("Unknown 'x-targetCfg.exposeScheme' '%s', supported are '%s', '%s' and '%s'", scheme, ir.ExposeSchemeIngress, ir.ExposeSchemeGatewayAPI, ir.ExposeSchemeOpenShiftRoute)
+		os.Exit(1)
+	}
+
+	for _, service := range inputs.Services {
+		for _, s := range append([]*ir.Service{&service.Service}, service.GetParts()...) {
+			if scheme == ir.ExposeSchemeGatewayAPI {
+				// invalid labels are reported by IngressPrecheck
+				ingressConfigs, _ := s.GetIngressConfigs()
+				for _, ingressConfig := range ingressConfigs {
+					if ingressConfig.PathType == "ImplementationSpecific" {
+						logrus.Errorf("Service '%s': HTTPRoutes have no equivalent of the path type 'ImplementationSpecific', use 'Prefix' or 'Exact' for port %d", s.Name, ingressConfig.Port)
+						os.Exit(1)
+					}
+				}
+			}
+			if scheme == ir.ExposeSchemeOpenShiftRoute {
+				// invalid labels are reported by IngressPrecheck
+				ingressConfigs, _ := s.GetIngressConfigs()
+				for _, ingressConfig := range ingressConfigs {
+					if ingressConfig.PathType != "Prefix" {
+						logrus.Errorf("Service '%s': OpenShift Routes only support the path type 'Prefix', got '%s' for port %d", s.Name, ingressConfig.PathType, ingressConfig.Port)
+						os.Exit(1)
+					}
+					if ingressConfig.TLSTermination == "passthrough" && ingressConfig.Path != "/" {
+						logrus.Errorf("Service '%s': OpenShift Routes with TLS termination 'passthrough' can't be restricted to a path, got '%s' for port %d", s.Name, ingressConfig.Path, ingressConfig.Port)
+						os.Exit(1)
+					}
+					if ingressConfig.TLSTermination == "passthrough" && ingressConfig.InsecureEdgeTerminationPolicy == "Allow" {
+						logrus.Errorf("Service '%s': OpenShift Routes with TLS termination 'passthrough' don't support the insecure edge termination policy 'Allow' (port %d)", s.Name, ingressConfig.Port)
+						os.Exit(1)
+					}
+				}
+			}
+			for _, port := range s.GetPorts() {
+				if util.ServiceGatewayListener(service.Labels(), int32(port.ServicePort)) != "" && scheme != ir.ExposeSchemeGatewayAPI {
+					logrus.Errorf("Service '%s': 'k8ify.exposePlain.%d.gatewayListener' requires 'x-targetCfg.exposeScheme: %s'", s.Name, port.ServicePort, ir.ExposeSchemeGatewayAPI)
+					os.Exit(1)
+				}
+			}
+		}
+	}
+}
+
+// IngressControllerPrecheck makes sure the ingress controller can implement the features of the exposed ports. All
+// exposed ports of a service share a single Ingress, hence they have to share the features, too.
+func IngressControllerPrecheck(inputs *ir.Inputs) {
+	controller := inputs.TargetCfg.IngressController()
+	switch controller {
+	case "", ir.IngressControllerNginx, ir.IngressControllerTraefik, ir.IngressControllerHAProxy:
+	default:
+		logrus.Errorf("Unknown 'x-targetCfg.ingressController' '%s', supported are '%s', '%s' and '%s'", controller, ir.IngressControllerNginx, ir.IngressControllerTraefik, ir.IngressControllerHAProxy)
+		os.Exit(1)
+	}
+
+	for _, service := range inputs.Services {
+		var features *ir.IngressFeatures
+		for _, s := range append([]*ir.Service{&service.Service}, service.GetParts()...) {
+			// invalid labels are reported by IngressPrecheck
+			ingressConfigs, _ := s.GetIngressConfigs()
+			for _, ingressConfig := range ingressConfigs {
+				if features == nil {
+					features = &ingressConfig.Features
+				} else if !reflect.DeepEqual(*features, ingressConfig.Features) {
+					logrus.Errorf("Service '%s': All exposed ports share a single Ingress, hence they must use the same 'maxBodySize', 'allowFrom', 'basicAuth', 'timeout' and 'redirectToHTTPS' settings", service.Name)
+					os.Exit(1)
+				}
+			}
+		}
+		if features == nil || features.IsEmpty() {
+			continue
+		}
+		if inputs.TargetCfg.ExposeScheme() != ir.ExposeSchemeIngress {
+			logrus.Errorf("Service '%s': 'maxBodySize', 'allowFrom', 'basicAuth', 'timeout' and 'redirectToHTTPS' require 'x-targetCfg.exposeScheme: %s'", service.Name, ir.ExposeSchemeIngress)
+			os.Exit(1)
+		}
+		if controller == "" {
+			logrus.Errorf("Service '%s': 'maxBodySize', 'allowFrom', 'basicAuth', 'timeout' and 'redirectToHTTPS' are implemented by the ingress controller. Set 'x-targetCfg.ingressController'.", service.Name)
+			os.Exit(1)
+		}
+		if controller == ir.IngressControllerTraefik && inputs.TargetCfg.Namespace() == "" {
+			logrus.Errorf("Service '%s': Traefik references its Middlewares including their namespace. Set 'x-targetCfg.namespace'.", service.Name)
+			os.Exit(1)
+		}
+	}
+}
+
+// CertManagerPrecheck makes sure a cert-manager issuer is configured if certificates are needed
+func CertManagerPrecheck(inputs *ir.Inputs) {
+	if inputs.TargetCfg.CertManagerIssuer() != "" && inputs.TargetCfg.CertManagerClusterIssuer() != "" {
+		logrus.Errorf("Only one of 'x-targetCfg.certManager.issuer' and 'x-targetCfg.certManager.clusterIssuer' may be set")
+		os.Exit(1)
+	}
+	if inputs.TargetCfg.CertManagerIssuer() != "" || inputs.TargetCfg.CertManagerClusterIssuer() != "" {
+		return
+	}
+	for _, service := range inputs.Services {
+		for _, s := range append([]*ir.Service{&service.Service}, service.GetParts()...) {
+			for _, port := range s.GetPorts() {
+				if len(util.ServiceTLSHosts(service.Labels(), int32(port.ServicePort))) > 0 {
+					logrus.Errorf("Service '%s': 'k8ify.exposePlain.%d.tlsHost' requires cert-manager. Set 'x-targetCfg.certManager.issuer' or 'x-targetCfg.certManager.clusterIssuer'.", s.Name, port.ServicePort)
+					os.Exit(1)
+				}
+			}
+		}
+	}
+}
+
+func FileObjectsPrecheck(inputs *ir.Inputs) {
+	for kind, fileObjects := range map[string]map[string]*ir.FileObject{"Config": inputs.Configs, "Secret": inputs.Secrets} {
+		for name, fileObject := range fileObjects {
+			if fileObject.IsExternal() {
+				continue
+			}
+			// CHECK: Content can't be read
+			if _, err := fileObject.Content(); err != nil {
+				logrus.Errorf("%s %q: %s", kind, name, err.Error())
 				os.Exit(1)
 			}
 		}
@@ -23,12 +23,19 @@ import (
 var (
 	defaultConfig = internal.Config{
 		OutputDir: "manifests",
+		Format:    internal.FormatManifests,
 		Env:       "dev",
 		Ref:       "",
 	}
 	modifiedImages   internal.ModifiedImagesFlag
 	shellEnvFiles    internal.ShellEnvFilesFlag
 	composeFiles     internal.StringSliceFlag
+	outputDir        string
+	format           string
+	kustomizeNs      string
+	kustomizeLabels  internal.StringSliceFlag
+	helmChartName    string
+	helmChartVersion string
 	pflagInitialized = false
 )
 
@@ -37,12 +44,20 @@ func InitPflag() {
 	if !pflagInitialized {
 		pflag.Var(&modifiedImages, "modified-image", "Image that has been modified during the build. Can be repeated.")
 		pflag.Var(&shellEnvFiles, "shell-env-file", "Shell environment file ('key=value' format) to be used in addition to the current shell environment. Can be repeated.")
+		pflag.StringVarP(&outputDir, "output", "o", defaultConfig.OutputDir, "Directory to write the manifests to. Use '-' to write all manifests to stdout as a single YAML stream instead.")
+		pflag.StringVar(&format, "format", defaultConfig.Format, "Output format: 'manifests' writes one file per resource, 'kustomize' additionally writes a kustomization.yaml listing all resources, 'helm' writes a Helm chart.")
+		pflag.StringVar(&kustomizeNs, "kustomize-namespace", "", "Namespace to set in the generated kustomization.yaml. Takes precedence over 'x-targetCfg.kustomize.namespace'.")
+		pflag.Var(&kustomizeLabels, "kustomize-common-label", "Label ('key=value') to add to 'commonLabels' in the generated kustomization.yaml. Can be repeated.")
+		pflag.StringVar(&helmChartName, "helm-chart-name", "", "Name of the generated Helm chart. Defaults to the name of the current working directory.")
+		pflag.StringVar(&helmChartVersion, "helm-chart-version", "0.1.0", "Version of the generated Helm chart.")
 		pflag.VarP(&composeFiles, "file", "f", "Compose file to convert instead of the automatic discovery. Can be repeated to merge multiple files, like 'docker compose -f'. Takes precedence over the COMPOSE_FILE environment variable. For each file an environment specific override is also loaded by inserting '-<env>' before the extension (e.g. compose-prod.yml for compose.yml with env prod), so listing only the base file is enough.\n\nThe COMPOSE_FILE environment variable offers the same selection when the flag is not set. It lists one or more files separated by the COMPOSE_PATH_SEPARATOR variable (defaulting to the OS path list separator, ':' on Linux/macOS, ';' on Windows).")
 		pflagInitialized = true
 	}
 }
 
 func main() {
+	// Logging must never end up on stdout, as the manifests may be written there
+	logrus.SetOutput(os.Stderr)
 	code := Main(os.Args)
 	os.Exit(code)
 }
@@ -53,6 +68,12 @@ func Main(args []string) int {
 	modifiedImages.Values = nil
 	shellEnvFiles.Values = nil
 	composeFiles.Values = nil
+	outputDir = defaultConfig.OutputDir
+	format = defaultConfig.Format
+	kustomizeNs = ""
+	kustomizeLabels.Values = nil
+	helmChartName = ""
+	helmChartVersion = "0.1.0"
 	err := pflag.CommandLine.Parse(args[1:])
 	if err != nil {
 		logrus.Error(err)
@@ -61,12 +82,29 @@ func Main(args []string) int {
 	plainArgs := pflag.Args()
 
 	config := defaultConfig // this code may run multiple times during testing, thus we can't modify the defaults and must create a copy
+	config.OutputDir = outputDir
+	config.Format = format
+	if config.Format != internal.FormatManifests && config.Format != internal.FormatKustomize && config.Format != internal.FormatHelm {
+		logrus.Errorf("Unknown output format '%s'", config.Format)
+		return 1
+	}
+	if config.Format != internal.FormatManifests && config.OutputDir == internal.StdoutOutput {
+		logrus.Errorf("Output format '%s' can't be written to stdout", config.Format)
+		return 1
+	}
 	if len(plainArgs) > 0 {
 		config.Env = plainArgs[0]
 	}
 	if len(plainArgs) > 1 {
 		config.Ref = plainArgs[1]
 	}
+	if config.Format == internal.FormatHelm {
+		// In a Helm chart the ref is replaced by the name of the Helm release
+		if config.Ref != "" {
+			logrus.Warnf("Ignoring ref '%s', Helm charts use the name of the Helm release instead", config.Ref)
+		}
+		config.Ref = internal.HelmReleaseRef
+	}
 
 	// Load the additional shell environment files first. This merges everything into the existing shell environment
 	// (retrievable via os.Environ()) so that variables defined there - e.g. COMPOSE_FILE - are available below.
@@ -128,21 +166,58 @@ func Main(args []string) int {
 	internal.ComposeServicePrecheck(inputs)
 	internal.VolumesPrecheck(inputs)
 	internal.DomainLengthPrecheck(inputs)
+	internal.IngressPrecheck(inputs)
+	internal.ExposeSchemePrecheck(inputs)
+	internal.IngressControllerPrecheck(inputs)
+	internal.CertManagerPrecheck(inputs)
+	internal.FileObjectsPrecheck(inputs)
 
 	objects := converter.Objects{}
 
 	for _, service := range inputs.Services {
-		objects = objects.Append(converter.ComposeServiceToK8s(config.Ref, service, inputs.Volumes, inputs.TargetCfg))
+		objects = objects.Append(converter.ComposeServiceToK8s(config.Ref, service, inputs.Services, inputs.Volumes, inputs.Configs, inputs.Secrets, inputs.TargetCfg))
 	}
 
 	forceRestartAnnotation := make(map[string]string)
 	forceRestartAnnotation["k8ify.restart-trigger"] = fmt.Sprintf("%d", time.Now().Unix())
-	converter.PatchDeployments(objects.Deployments, modifiedImages.Values, objects.Secrets, forceRestartAnnotation)
-	converter.PatchStatefulSets(objects.StatefulSets, modifiedImages.Values, objects.Secrets, forceRestartAnnotation)
+	converter.PatchDeployments(objects.Deployments, modifiedImages.Values, objects.Secrets, objects.ConfigMaps, forceRestartAnnotation)
+	converter.PatchStatefulSets(objects.StatefulSets, modifiedImages.Values, objects.Secrets, objects.ConfigMaps, forceRestartAnnotation)
+	converter.PatchDaemonSets(objects.DaemonSets, modifiedImages.Values, objects.Secrets, objects.ConfigMaps, forceRestartAnnotation)
+	converter.PatchCronJobs(objects.CronJobs, modifiedImages.Values, objects.Secrets, objects.ConfigMaps, forceRestartAnnotation)
 
 	objects = provider.PatchEncryptedVolumeSchemeAppuioCloudscale(inputs.TargetCfg, config, objects)
 
-	err = internal.WriteManifests(config.OutputDir, objects)
+	if config.OutputDir == internal.StdoutOutput {
+		err = internal.WriteManifestStream(os.Stdout, objects)
+	} else if config.Format == internal.FormatKustomize {
+		namespace := inputs.TargetCfg.KustomizeNamespace()
+		if kustomizeNs != "" {
+			namespace = kustomizeNs
+		}
+		commonLabels := inputs.TargetCfg.KustomizeCommonLabels()
+		for _, label := range kustomizeLabels.Values {
+			key, value, found := strings.Cut(label, "=")
+			if !found {
+				logrus.Errorf("Invalid kustomize common label '%s', expected 'key=value'", label)
+				return 1
+			}
+			commonLabels[key] = value
+		}
+		err = internal.WriteKustomization(config.OutputDir, objects, namespace, commonLabels)
+	} else if config.Format == internal.FormatHelm {
+		chartName := helmChartName
+		if chartName == "" {
+			wd, err := os.Getwd()
+			if err != nil {
+				logrus.Errorf("Determining Helm chart name: %s", err)
+				return 1
+			}
+			chartName = util.Sanitize(filepath.Base(wd))
+		}
+		err = internal.WriteHelmChart(config.OutputDir, chartName, helmChartVersion, objects)
+	} else {
+		err = internal.WriteManifests(config.OutputDir, objects)
+	}
 	if err != nil {
 		logrus.Errorf("Writing manifests: %s", err)
 		return 1
@@ -0,0 +1,92 @@
+package converter
+
+import (
+	"github.com/vshn/k8ify/pkg/ir"
+	"github.com/vshn/k8ify/pkg/util"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const backupRepositoryPasswordSecretName = "backup-repository-password"
+
+// composeVolumeToBackup creates a K8up Schedule backing up the PersistentVolumeClaims matching `selector` if the volume
+// is labeled with `k8ify.backup: true`, along with the Secret holding the password of the backup repository. All
+// Schedules share the same repository, hence the same Secret, Objects.Append() takes care of deduplication. The backups
+// of each Schedule are tagged with `name` so that its retention doesn't affect the backups of other Schedules.
+func composeVolumeToBackup(name string, labels map[string]string, selector map[string]string, volume *ir.Volume, targetCfg ir.TargetCfg) ([]unstructured.Unstructured, []core.Secret) {
+	backupConfig, _ := ir.BackupConfigPointer(volume.Labels())
+	if backupConfig == nil {
+		return []unstructured.Unstructured{}, []core.Secret{}
+	}
+
+	name = util.Sanitize(name)
+	credentialsSecret := targetCfg.BackupCredentialsSecret()
+	schedule := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "k8up.io/v1",
+			"kind":       "Schedule",
+			"metadata": map[string]interface{}{
+				"name":   name + "-backup",
+				"labels": labels,
+			},
+			"spec": map[string]interface{}{
+				"backend": map[string]interface{}{
+					"repoPasswordSecretRef": map[string]interface{}{
+						"name": backupRepositoryPasswordSecretName,
+						"key":  "password",
+					},
+					"s3": map[string]interface{}{
+						"endpoint": targetCfg.BackupEndpoint(),
+						"bucket":   targetCfg.BackupBucket(),
+						"accessKeyIDSecretRef": map[string]interface{}{
+							"name": credentialsSecret,
+							"key":  "username",
+						},
+						"secretAccessKeySecretRef": map[string]interface{}{
+							"name": credentialsSecret,
+							"key":  "password",
+						},
+					},
+				},
+				"backup": map[string]interface{}{
+					"schedule": backupConfig.Schedule,
+					"tags":     []interface{}{name},
+					"labelSelectors": []interface{}{
+						map[string]interface{}{
+							"matchLabels": selector,
+						},
+					},
+				},
+				"prune": map[string]interface{}{
+					"schedule": "@weekly-random",
+					"retention": map[string]interface{}{
+						"keepDaily": int64(backupConfig.KeepDaily),
+						"tags":      []interface{}{name},
+					},
+				},
+			},
+		},
+	}
+
+	secret := core.Secret{}
+	secret.APIVersion = "v1"
+	secret.Kind = "Secret"
+	secret.Name = backupRepositoryPasswordSecretName
+	secret.StringData = map[string]string{"password": targetCfg.BackupRepositoryPassword()}
+
+	return []unstructured.Unstructured{schedule}, []core.Secret{secret}
+}
+
+// mountsBackedUpVolume returns true if the service or one of its parts mounts a volume that is backed up
+func mountsBackedUpVolume(workload *ir.ParentService, projectVolumes map[string]*ir.Volume) bool {
+	for _, service := range append([]*ir.Service{&workload.Service}, workload.GetParts()...) {
+		for _, volumeName := range service.VolumeNames() {
+			if volume, ok := projectVolumes[volumeName]; ok {
+				if backupConfig, _ := ir.BackupConfigPointer(volume.Labels()); backupConfig != nil {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package converter
+
+import (
+	"slices"
+
+	"github.com/vshn/k8ify/pkg/ir"
+	"github.com/vshn/k8ify/pkg/util"
+	core "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// tlsMountPath is where the certificate of plain exposed ports is mounted, next to the Compose secrets
+const tlsMountPath = "/run/secrets/tls"
+
+// composeServiceToCertificate creates a cert-manager Certificate for the hosts of all plain exposed ports labeled with
+// `k8ify.exposePlain.$port.tlsHost`. Unlike with Ingresses, nothing terminates TLS in front of these ports, the service
+// has to do it itself using the certificate mounted by composeServiceTLSToK8s.
+func composeServiceToCertificate(workload *ir.ParentService, refSlug string, labels map[string]string, targetCfg ir.TargetCfg) []unstructured.Unstructured {
+	hosts := []interface{}{}
+	for _, service := range append([]*ir.Service{&workload.Service}, workload.GetParts()...) {
+		for _, host := range serviceTLSHosts(workload, service) {
+			if !slices.Contains(hosts, interface{}(host)) {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	if len(hosts) == 0 {
+		return []unstructured.Unstructured{}
+	}
+
+	name := workload.Name + refSlug + "-tls"
+	certificate := newUnstructured("cert-manager.io/v1", "Certificate", name, workload, labels)
+	certificate.Object["spec"] = map[string]interface{}{
+		"secretName": name,
+		"dnsNames":   hosts,
+		"issuerRef":  certManagerIssuerRef(targetCfg),
+	}
+	return []unstructured.Unstructured{certificate}
+}
+
+// composeServiceTLSToK8s mounts the Secret of the Certificate created by composeServiceToCertificate into the containers
+// publishing a port labeled with `k8ify.exposePlain.$port.tlsHost`. The first container belongs to the parent service,
+// the others to its parts.
+func composeServiceTLSToK8s(workload *ir.ParentService, refSlug string, containers []core.Container) map[string]core.Volume {
+	volumes := make(map[string]core.Volume)
+	volumeName := workload.Name + "-tls"
+	for i, service := range append([]*ir.Service{&workload.Service}, workload.GetParts()...) {
+		if len(serviceTLSHosts(workload, service)) == 0 {
+			continue
+		}
+		volumes[volumeName] = core.Volume{
+			Name: volumeName,
+			VolumeSource: core.VolumeSource{
+				Secret: &core.SecretVolumeSource{
+					SecretName: workload.Name + refSlug + "-tls",
+				},
+			},
+		}
+		containers[i].VolumeMounts = append(containers[i].VolumeMounts, core.VolumeMount{
+			Name:      volumeName,
+			MountPath: tlsMountPath,
+			ReadOnly:  true,
+		})
+	}
+	return volumes
+}
+
+// serviceTLSHosts returns the hosts of the ports published by `service` which need a certificate. The ports of the parts
+// are configured via the labels of the parent, just like everything else related to `k8ify.exposePlain`.
+func serviceTLSHosts(workload *ir.ParentService, service *ir.Service) []string {
+	hosts := []string{}
+	for _, port := range service.GetPorts() {
+		hosts = append(hosts, util.ServiceTLSHosts(workload.Labels(), int32(port.ServicePort))...)
+	}
+	return hosts
+}
+
+// certManagerIngressAnnotations returns the annotations making cert-manager issue the certificates of the TLS entries
+// of an Ingress, or nothing if no issuer is configured or the Ingress only uses the apps domain wildcard certificate
+func certManagerIngressAnnotations(tls []networking.IngressTLS, targetCfg ir.TargetCfg) map[string]string {
+	annotations := map[string]string{}
+	if !slices.ContainsFunc(tls, func(t networking.IngressTLS) bool { return t.SecretName != "" }) {
+		return annotations
+	}
+	if issuer := targetCfg.CertManagerIssuer(); issuer != "" {
+		annotations["cert-manager.io/issuer"] = issuer
+	}
+	if clusterIssuer := targetCfg.CertManagerClusterIssuer(); clusterIssuer != "" {
+		annotations["cert-manager.io/cluster-issuer"] = clusterIssuer
+	}
+	return annotations
+}
+
+func certManagerIssuerRef(targetCfg ir.TargetCfg) map[string]interface{} {
+	if clusterIssuer := targetCfg.CertManagerClusterIssuer(); clusterIssuer != "" {
+		return map[string]interface{}{
+			"name": clusterIssuer,
+			"kind": "ClusterIssuer",
+		}
+	}
+	return map[string]interface{}{
+		"name": targetCfg.CertManagerIssuer(),
+		"kind": "Issuer",
+	}
+}
@@ -6,15 +6,19 @@ import (
 	"maps"
 	"os"
 	"os/exec"
+	"slices"
 	"sort"
 	"strings"
 
 	composeTypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/go-units"
 	"github.com/sirupsen/logrus"
 	"github.com/vshn/k8ify/pkg/ir"
 	"github.com/vshn/k8ify/pkg/provider/networkpolicy"
 	"github.com/vshn/k8ify/pkg/util"
 	apps "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	v1 "k8s.io/api/policy/v1"
@@ -55,7 +59,16 @@ func composeServiceVolumesToK8s(
 		})
 
 		volume := projectVolumes[mount.Source]
-		if volume.IsShared() {
+		if volume.IsExternal() {
+			volumes[name] = core.Volume{
+				Name: name,
+				VolumeSource: core.VolumeSource{
+					PersistentVolumeClaim: &core.PersistentVolumeClaimVolumeSource{
+						ClaimName: volume.ExternalName(),
+					},
+				},
+			}
+		} else if volume.IsShared() {
 			volumes[name] = core.Volume{
 				Name: name,
 				VolumeSource: core.VolumeSource{
@@ -69,31 +82,74 @@ func composeServiceVolumesToK8s(
 	return volumes, volumeMounts
 }
 
-func composeServiceTmpfsToK8s(
-	container string,
-	paths []string,
-) (map[string]core.Volume, []core.VolumeMount) {
+// composeServiceTmpfsToK8s converts `tmpfs` (e.g. "/run:size=64m"), volumes of type tmpfs and `shm_size` into
+// memory-backed emptyDirs. Returns the sum of their sizes as well, since the content of memory-backed emptyDirs counts
+// towards the memory usage of the container.
+func composeServiceTmpfsToK8s(composeService composeTypes.ServiceConfig) (map[string]core.Volume, []core.VolumeMount, int64) {
 	volumeMounts := []core.VolumeMount{}
 	volumes := make(map[string]core.Volume)
+	totalSize := int64(0)
 
-	for _, path := range paths {
-		// MinLength of 3 because the most common use case is "/tmp", which results in a 3-letter-identifier
-		name := fmt.Sprintf("%s-tmpfs-%s", container, util.SanitizeWithMinLength(path, 3))
-
+	addTmpfs := func(name string, path string, size int64) {
 		volumeMounts = append(volumeMounts, core.VolumeMount{
 			Name:      name,
 			MountPath: path,
 		})
-
+		emptyDir := core.EmptyDirVolumeSource{Medium: core.StorageMediumMemory}
+		if size > 0 {
+			emptyDir.SizeLimit = resource.NewQuantity(size, resource.BinarySI)
+			totalSize += size
+		}
 		volumes[name] = core.Volume{
-			Name: name,
-			VolumeSource: core.VolumeSource{
-				EmptyDir: &core.EmptyDirVolumeSource{},
-			},
+			Name:         name,
+			VolumeSource: core.VolumeSource{EmptyDir: &emptyDir},
 		}
 	}
+	// MinLength of 3 because the most common use case is "/tmp", which results in a 3-letter-identifier
+	tmpfsName := func(path string) string {
+		return fmt.Sprintf("%s-tmpfs-%s", composeService.Name, util.SanitizeWithMinLength(path, 3))
+	}
 
-	return volumes, volumeMounts
+	for _, tmpfs := range composeService.Tmpfs {
+		path, options, _ := strings.Cut(tmpfs, ":")
+		size := int64(0)
+		for _, option := range strings.Split(options, ",") {
+			value, found := strings.CutPrefix(option, "size=")
+			if !found {
+				if option != "" {
+					logrus.Warnf("Service '%s': Ignoring option '%s' of tmpfs '%s', emptyDirs only support 'size'", composeService.Name, option, path)
+				}
+				continue
+			}
+			parsedSize, err := units.RAMInBytes(value)
+			if err != nil {
+				logrus.Warnf("Service '%s': Ignoring invalid size of tmpfs '%s': %s", composeService.Name, path, err)
+				continue
+			}
+			size = parsedSize
+		}
+		addTmpfs(tmpfsName(path), path, size)
+	}
+
+	for _, mount := range composeService.Volumes {
+		if mount.Type != composeTypes.VolumeTypeTmpfs {
+			continue
+		}
+		size := int64(0)
+		if mount.Tmpfs != nil {
+			size = int64(mount.Tmpfs.Size)
+			if mount.Tmpfs.Mode != 0 {
+				logrus.Warnf("Service '%s': Ignoring mode of tmpfs '%s', emptyDirs only support 'size'", composeService.Name, mount.Target)
+			}
+		}
+		addTmpfs(tmpfsName(mount.Target), mount.Target, size)
+	}
+
+	if composeService.ShmSize > 0 {
+		addTmpfs(composeService.Name+"-shm", "/dev/shm", int64(composeService.ShmSize))
+	}
+
+	return volumes, volumeMounts, totalSize
 }
 
 func composeServicePortsToK8sServicePorts(workload *ir.ParentService) []core.ServicePort {
@@ -105,12 +161,23 @@ func composeServicePortsToK8sServicePorts(workload *ir.ParentService) []core.Ser
 	return createServicePorts(ports)
 }
 
+// composeServiceExposedPortsToK8sServicePorts returns the ports of `expose` of the service and all its parts, which are
+// only reachable from within the cluster
+func composeServiceExposedPortsToK8sServicePorts(workload *ir.ParentService) []core.ServicePort {
+	ports := workload.GetExposedPorts()
+	for _, part := range workload.GetParts() {
+		ports = append(ports, part.GetExposedPorts()...)
+	}
+	return createServicePorts(ports)
+}
+
 func createServicePorts(ports []ir.PublishedPort) []core.ServicePort {
 	var servicePorts []core.ServicePort
 	for _, port := range ports {
 		servicePorts = append(servicePorts, core.ServicePort{
-			Name: fmt.Sprint(port.ServicePort),
-			Port: int32(port.ServicePort),
+			Name:     portName(port),
+			Port:     int32(port.ServicePort),
+			Protocol: toProtocol(port),
 			TargetPort: intstr.IntOrString{
 				IntVal: int32(port.ContainerPort),
 			},
@@ -119,11 +186,43 @@ func createServicePorts(ports []ir.PublishedPort) []core.ServicePort {
 	return servicePorts
 }
 
+// portName returns the number of the port, with the protocol appended for anything but TCP. This keeps the names
+// unique if the same port number is used for TCP and UDP (e.g. DNS).
+func portName(port ir.PublishedPort) string {
+	if port.IsTCP() {
+		return fmt.Sprint(port.ServicePort)
+	}
+	return fmt.Sprintf("%d-%s", port.ServicePort, strings.ToLower(port.Protocol))
+}
+
+// toProtocol returns the protocol of the port, or "" for TCP since that's the K8s default anyway
+func toProtocol(port ir.PublishedPort) core.Protocol {
+	if port.IsTCP() {
+		return ""
+	}
+	return core.Protocol(port.Protocol)
+}
+
 func composeServicePortsToK8sContainerPorts(workload *ir.Service) []core.ContainerPort {
 	containerPorts := []core.ContainerPort{}
-	for _, port := range workload.AsCompose().Ports {
+	for _, port := range workload.GetPorts() {
+		containerPort := core.ContainerPort{
+			ContainerPort: int32(port.ContainerPort),
+			Protocol:      toProtocol(port),
+		}
+		if port.HostMode {
+			containerPort.HostPort = int32(port.ServicePort)
+			containerPort.HostIP = port.HostIP
+		}
+		// multiple published ports may forward to the same container port, K8s warns about duplicates
+		if !slices.Contains(containerPorts, containerPort) {
+			containerPorts = append(containerPorts, containerPort)
+		}
+	}
+	for _, port := range workload.GetExposedPorts() {
 		containerPorts = append(containerPorts, core.ContainerPort{
-			ContainerPort: int32(port.Target),
+			ContainerPort: int32(port.ContainerPort),
+			Protocol:      toProtocol(port),
 		})
 	}
 	return containerPorts
@@ -173,7 +272,7 @@ func composeServiceToPullSecret(authConf string, name string, labels map[string]
 	return &secret
 }
 
-func composeServiceToDeployment(workload *ir.ParentService, refSlug string, projectVolumes map[string]*ir.Volume, labels map[string]string) (apps.Deployment, []core.Secret) {
+func composeServiceToDeployment(workload *ir.ParentService, refSlug string, projectVolumes map[string]*ir.Volume, projectConfigs map[string]*ir.FileObject, projectSecrets map[string]*ir.FileObject, labels map[string]string, targetCfg ir.TargetCfg) (apps.Deployment, []core.Secret, []core.ConfigMap) {
 
 	deployment := apps.Deployment{}
 	deployment.APIVersion = "apps/v1"
@@ -182,16 +281,19 @@ func composeServiceToDeployment(workload *ir.ParentService, refSlug string, proj
 	deployment.Labels = labels
 	deployment.Annotations = util.Annotations(workload.Labels(), "Deployment")
 
-	templateSpec, secrets := composeServiceToPodTemplate(
+	templateSpec, secrets, configMaps := composeServiceToPodTemplate(
 		workload,
 		refSlug,
 		projectVolumes,
+		projectConfigs,
+		projectSecrets,
 		labels,
 		util.ServiceAccountName(workload.AsCompose().Labels),
+		targetCfg,
 	)
 
 	deployment.Spec = apps.DeploymentSpec{
-		Replicas: composeServiceToReplicas(workload.AsCompose()),
+		Replicas: composeServiceToWorkloadReplicas(&workload.Service),
 		Strategy: composeServiceToStrategy(workload.AsCompose()),
 		Template: templateSpec,
 		Selector: &metav1.LabelSelector{
@@ -199,7 +301,7 @@ func composeServiceToDeployment(workload *ir.ParentService, refSlug string, proj
 		},
 	}
 
-	return deployment, secrets
+	return deployment, secrets, configMaps
 }
 
 func composeServiceToStrategy(composeService composeTypes.ServiceConfig) apps.DeploymentStrategy {
@@ -225,7 +327,7 @@ func getUpdateOrder(composeService composeTypes.ServiceConfig) string {
 	return composeService.Deploy.UpdateConfig.Order
 }
 
-func composeServiceToStatefulSet(workload *ir.ParentService, refSlug string, projectVolumes map[string]*ir.Volume, volumeClaims []core.PersistentVolumeClaim, labels map[string]string) (apps.StatefulSet, []core.Secret) {
+func composeServiceToStatefulSet(workload *ir.ParentService, refSlug string, projectVolumes map[string]*ir.Volume, projectConfigs map[string]*ir.FileObject, projectSecrets map[string]*ir.FileObject, volumeClaims []core.PersistentVolumeClaim, labels map[string]string, targetCfg ir.TargetCfg) (apps.StatefulSet, []core.Secret, []core.ConfigMap) {
 	statefulset := apps.StatefulSet{}
 	statefulset.APIVersion = "apps/v1"
 	statefulset.Kind = "StatefulSet"
@@ -233,17 +335,20 @@ func composeServiceToStatefulSet(workload *ir.ParentService, refSlug string, pro
 	statefulset.Labels = labels
 	statefulset.Annotations = util.Annotations(workload.Labels(), "StatefulSet")
 
-	templateSpec, secrets := composeServiceToPodTemplate(
+	templateSpec, secrets, configMaps := composeServiceToPodTemplate(
 		workload,
 		refSlug,
 		projectVolumes,
+		projectConfigs,
+		projectSecrets,
 		labels,
 		util.ServiceAccountName(workload.AsCompose().Labels),
+		targetCfg,
 	)
 
 	statefulset.Spec = apps.StatefulSetSpec{
 		ServiceName: workload.Name + refSlug,
-		Replicas:    composeServiceToReplicas(workload.AsCompose()),
+		Replicas:    composeServiceToWorkloadReplicas(&workload.Service),
 		Template:    templateSpec,
 		Selector: &metav1.LabelSelector{
 			MatchLabels: labels,
@@ -251,7 +356,131 @@ func composeServiceToStatefulSet(workload *ir.ParentService, refSlug string, pro
 		VolumeClaimTemplates: volumeClaims,
 	}
 
-	return statefulset, secrets
+	return statefulset, secrets, configMaps
+}
+
+func composeServiceToDaemonSet(workload *ir.ParentService, refSlug string, projectVolumes map[string]*ir.Volume, projectConfigs map[string]*ir.FileObject, projectSecrets map[string]*ir.FileObject, labels map[string]string, targetCfg ir.TargetCfg) (apps.DaemonSet, []core.Secret, []core.ConfigMap) {
+	daemonSet := apps.DaemonSet{}
+	daemonSet.APIVersion = "apps/v1"
+	daemonSet.Kind = "DaemonSet"
+	daemonSet.Name = workload.Name + refSlug
+	daemonSet.Labels = labels
+	daemonSet.Annotations = util.Annotations(workload.Labels(), "DaemonSet")
+
+	templateSpec, secrets, configMaps := composeServiceToPodTemplate(
+		workload,
+		refSlug,
+		projectVolumes,
+		projectConfigs,
+		projectSecrets,
+		labels,
+		util.ServiceAccountName(workload.AsCompose().Labels),
+		targetCfg,
+	)
+	// A DaemonSet runs exactly one pod per node anyway
+	templateSpec.Spec.Affinity = nil
+
+	daemonSet.Spec = apps.DaemonSetSpec{
+		UpdateStrategy: composeServiceToDaemonSetUpdateStrategy(workload.AsCompose()),
+		Template:       templateSpec,
+		Selector: &metav1.LabelSelector{
+			MatchLabels: labels,
+		},
+	}
+
+	return daemonSet, secrets, configMaps
+}
+
+// composeServiceToDaemonSetUpdateStrategy maps the update order to a rolling update. Since a DaemonSet can't recreate
+// all pods at once, "stop-first" replaces one pod after the other, "start-first" starts the new pod on a node before
+// stopping the old one.
+func composeServiceToDaemonSetUpdateStrategy(composeService composeTypes.ServiceConfig) apps.DaemonSetUpdateStrategy {
+	strategy := apps.DaemonSetUpdateStrategy{
+		Type: apps.RollingUpdateDaemonSetStrategyType,
+	}
+	if getUpdateOrder(composeService) == "start-first" {
+		maxSurge := intstr.FromInt32(1)
+		maxUnavailable := intstr.FromInt32(0)
+		strategy.RollingUpdate = &apps.RollingUpdateDaemonSet{
+			MaxSurge:       &maxSurge,
+			MaxUnavailable: &maxUnavailable,
+		}
+	}
+	return strategy
+}
+
+func composeServiceToJobSpec(workload *ir.ParentService, refSlug string, projectVolumes map[string]*ir.Volume, projectConfigs map[string]*ir.FileObject, projectSecrets map[string]*ir.FileObject, labels map[string]string, targetCfg ir.TargetCfg, jobConfig *ir.JobConfig) (batch.JobSpec, []core.Secret, []core.ConfigMap) {
+	templateSpec, secrets, configMaps := composeServiceToPodTemplate(
+		workload,
+		refSlug,
+		projectVolumes,
+		projectConfigs,
+		projectSecrets,
+		labels,
+		util.ServiceAccountName(workload.AsCompose().Labels),
+		targetCfg,
+	)
+	// Jobs run to completion, hence their pods must not be restarted forever. The anti-affinity is not needed either,
+	// it would only prevent concurrent runs on the same node.
+	templateSpec.Spec.RestartPolicy = composeServiceToJobRestartPolicy(workload.AsCompose())
+	templateSpec.Spec.Affinity = nil
+
+	return batch.JobSpec{
+		BackoffLimit:            jobConfig.BackoffLimit,
+		TTLSecondsAfterFinished: jobConfig.TTLSecondsAfterFinished,
+		Template:                templateSpec,
+	}, secrets, configMaps
+}
+
+// composeServiceToJobRestartPolicy maps the Compose restart policy to the ones supported by Jobs. Unless restarting is
+// disabled explicitly, failed containers are restarted.
+func composeServiceToJobRestartPolicy(composeService composeTypes.ServiceConfig) core.RestartPolicy {
+	if composeService.Restart == composeTypes.RestartPolicyNo {
+		return core.RestartPolicyNever
+	}
+	if composeService.Deploy != nil && composeService.Deploy.RestartPolicy != nil && composeService.Deploy.RestartPolicy.Condition == "none" {
+		return core.RestartPolicyNever
+	}
+	return core.RestartPolicyOnFailure
+}
+
+func composeServiceToJob(workload *ir.ParentService, refSlug string, projectVolumes map[string]*ir.Volume, projectConfigs map[string]*ir.FileObject, projectSecrets map[string]*ir.FileObject, labels map[string]string, targetCfg ir.TargetCfg, jobConfig *ir.JobConfig) (batch.Job, []core.Secret, []core.ConfigMap) {
+	job := batch.Job{}
+	job.APIVersion = "batch/v1"
+	job.Kind = "Job"
+	job.Name = workload.Name + refSlug
+	job.Labels = labels
+	job.Annotations = util.Annotations(workload.Labels(), "Job")
+
+	jobSpec, secrets, configMaps := composeServiceToJobSpec(workload, refSlug, projectVolumes, projectConfigs, projectSecrets, labels, targetCfg, jobConfig)
+	job.Spec = jobSpec
+
+	return job, secrets, configMaps
+}
+
+func composeServiceToCronJob(workload *ir.ParentService, refSlug string, projectVolumes map[string]*ir.Volume, projectConfigs map[string]*ir.FileObject, projectSecrets map[string]*ir.FileObject, labels map[string]string, targetCfg ir.TargetCfg, jobConfig *ir.JobConfig) (batch.CronJob, []core.Secret, []core.ConfigMap) {
+	cronJob := batch.CronJob{}
+	cronJob.APIVersion = "batch/v1"
+	cronJob.Kind = "CronJob"
+	cronJob.Name = workload.Name + refSlug
+	cronJob.Labels = labels
+	cronJob.Annotations = util.Annotations(workload.Labels(), "CronJob")
+
+	jobSpec, secrets, configMaps := composeServiceToJobSpec(workload, refSlug, projectVolumes, projectConfigs, projectSecrets, labels, targetCfg, jobConfig)
+	cronJob.Spec = batch.CronJobSpec{
+		Schedule: *jobConfig.Schedule,
+		JobTemplate: batch.JobTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: labels,
+			},
+			Spec: jobSpec,
+		},
+	}
+	if jobConfig.ConcurrencyPolicy != nil {
+		cronJob.Spec.ConcurrencyPolicy = batch.ConcurrencyPolicy(*jobConfig.ConcurrencyPolicy)
+	}
+
+	return cronJob, secrets, configMaps
 }
 
 func composeServiceToReplicas(composeService composeTypes.ServiceConfig) *int32 {
@@ -264,19 +493,76 @@ func composeServiceToReplicas(composeService composeTypes.ServiceConfig) *int32
 	return ptr.To(int32(*deploy.Replicas))
 }
 
+// composeServiceToWorkloadReplicas returns the replicas to be set on a Deployment or StatefulSet. If the service is
+// autoscaled the replicas are left to the HorizontalPodAutoscaler, otherwise both would fight over the value.
+func composeServiceToWorkloadReplicas(workload *ir.Service) *int32 {
+	autoscaleConfig, _ := ir.AutoscaleConfigPointer(workload.Labels())
+	if autoscaleConfig != nil {
+		return nil
+	}
+	return composeServiceToReplicas(workload.AsCompose())
+}
+
+func composeServiceToHorizontalPodAutoscaler(workload *ir.Service, refSlug string, targetKind string, labels map[string]string) *autoscaling.HorizontalPodAutoscaler {
+	config, _ := ir.AutoscaleConfigPointer(workload.Labels())
+	if config == nil {
+		return nil
+	}
+
+	metrics := []autoscaling.MetricSpec{}
+	for _, target := range []struct {
+		name        core.ResourceName
+		utilization *int32
+	}{
+		{name: core.ResourceCPU, utilization: config.Cpu},
+		{name: core.ResourceMemory, utilization: config.Memory},
+	} {
+		if target.utilization == nil {
+			continue
+		}
+		metrics = append(metrics, autoscaling.MetricSpec{
+			Type: autoscaling.ResourceMetricSourceType,
+			Resource: &autoscaling.ResourceMetricSource{
+				Name: target.name,
+				Target: autoscaling.MetricTarget{
+					Type:               autoscaling.UtilizationMetricType,
+					AverageUtilization: target.utilization,
+				},
+			},
+		})
+	}
+
+	horizontalPodAutoscaler := autoscaling.HorizontalPodAutoscaler{}
+	horizontalPodAutoscaler.APIVersion = "autoscaling/v2"
+	horizontalPodAutoscaler.Kind = "HorizontalPodAutoscaler"
+	horizontalPodAutoscaler.Name = workload.Name + refSlug
+	horizontalPodAutoscaler.Labels = labels
+	horizontalPodAutoscaler.Annotations = util.Annotations(workload.Labels(), horizontalPodAutoscaler.Kind)
+	horizontalPodAutoscaler.Spec = autoscaling.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscaling.CrossVersionObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       targetKind,
+			Name:       workload.Name + refSlug,
+		},
+		MinReplicas: &config.Min,
+		MaxReplicas: config.Max,
+		Metrics:     metrics,
+	}
+	return &horizontalPodAutoscaler
+}
+
 func composeServiceToPodTemplate(
 	workload *ir.ParentService,
 	refSlug string,
 	projectVolumes map[string]*ir.Volume,
+	projectConfigs map[string]*ir.FileObject,
+	projectSecrets map[string]*ir.FileObject,
 	labels map[string]string,
 	serviceAccountName string,
-) (core.PodTemplateSpec, []core.Secret) {
-	container, secret, volumes := composeServiceToContainer(workload, refSlug, projectVolumes, labels)
+	targetCfg ir.TargetCfg,
+) (core.PodTemplateSpec, []core.Secret, []core.ConfigMap) {
+	container, secrets, configMaps, volumes := composeServiceToContainer(workload, refSlug, projectVolumes, projectConfigs, projectSecrets, labels, targetCfg)
 	containers := []core.Container{container}
-	secrets := []core.Secret{}
-	if secret != nil {
-		secrets = append(secrets, *secret)
-	}
 	imagePullSecretReference := []core.LocalObjectReference{}
 	if util.ImagePullSecret(workload.AsCompose().Labels) != nil {
 		imagePullSecret := composeServiceToPullSecret(
@@ -288,11 +574,10 @@ func composeServiceToPodTemplate(
 	}
 	for _, part := range workload.GetParts() {
 		fakeParent := ir.ParentService{Service: *part}
-		c, s, cvs := composeServiceToContainer(&fakeParent, refSlug, projectVolumes, labels)
+		c, s, cms, cvs := composeServiceToContainer(&fakeParent, refSlug, projectVolumes, projectConfigs, projectSecrets, labels, targetCfg)
 		containers = append(containers, c)
-		if s != nil {
-			secrets = append(secrets, *s)
-		}
+		secrets = append(secrets, s...)
+		configMaps = append(configMaps, cms...)
 		if util.ImagePullSecret(part.AsCompose().Labels) != nil {
 			imagePullSecret := composeServiceToPullSecret(
 				*util.ImagePullSecret(part.AsCompose().Labels),
@@ -304,6 +589,7 @@ func composeServiceToPodTemplate(
 		}
 		maps.Copy(volumes, cvs)
 	}
+	maps.Copy(volumes, composeServiceTLSToK8s(workload, refSlug, containers))
 
 	// make sure the array is sorted to have deterministic output
 	keys := make([]string, 0, len(volumes))
@@ -326,15 +612,22 @@ func composeServiceToPodTemplate(
 		Volumes:            volumesArray,
 		ServiceAccountName: serviceAccountName,
 		Affinity:           composeServiceToAffinity(&workload.Service),
+		SecurityContext:    composeServiceToPodSecurityContext(workload, targetCfg),
+	}
+
+	annotations := util.Annotations(workload.Labels(), "Pod")
+	if mountsBackedUpVolume(workload, projectVolumes) {
+		// mark the pods whose data is backed up by K8up, just like their PersistentVolumeClaims
+		annotations["k8up.io/backup"] = "true"
 	}
 
 	return core.PodTemplateSpec{
 		Spec: podSpec,
 		ObjectMeta: metav1.ObjectMeta{
 			Labels:      labels,
-			Annotations: util.Annotations(workload.Labels(), "Pod"),
+			Annotations: annotations,
 		},
-	}, secrets
+	}, secrets, configMaps
 }
 
 func composeServiceToAffinity(workload *ir.Service) *core.Affinity {
@@ -364,28 +657,44 @@ func composeServiceToContainer(
 	workload *ir.ParentService,
 	refSlug string,
 	projectVolumes map[string]*ir.Volume,
+	projectConfigs map[string]*ir.FileObject,
+	projectSecrets map[string]*ir.FileObject,
 	labels map[string]string,
-) (core.Container, *core.Secret, map[string]core.Volume) {
+	targetCfg ir.TargetCfg,
+) (core.Container, []core.Secret, []core.ConfigMap, map[string]core.Volume) {
 	composeService := workload.AsCompose()
 
 	volumes, volumeMounts := composeServiceVolumesToK8s(
 		refSlug, workload.AsCompose().Volumes, projectVolumes,
 	)
-	emptyDirVolumes, emptyDirVolumeMounts := composeServiceTmpfsToK8s(composeService.Name, workload.AsCompose().Tmpfs)
+	emptyDirVolumes, emptyDirVolumeMounts, emptyDirSize := composeServiceTmpfsToK8s(composeService)
 	for k, v := range emptyDirVolumes {
 		volumes[k] = v
 	}
 	volumeMounts = append(volumeMounts, emptyDirVolumeMounts...)
+	bindVolumes, bindVolumeMounts, bindConfigMaps := composeServiceBindMountsToK8s(&workload.Service, refSlug, labels, targetCfg)
+	maps.Copy(volumes, bindVolumes)
+	volumeMounts = append(volumeMounts, bindVolumeMounts...)
+	configVolumes, configVolumeMounts, configMaps := composeServiceConfigsToK8s(&workload.Service, refSlug, projectConfigs, labels)
+	configMaps = append(bindConfigMaps, configMaps...)
+	maps.Copy(volumes, configVolumes)
+	volumeMounts = append(volumeMounts, configVolumeMounts...)
+	secretVolumes, secretVolumeMounts, fileSecrets := composeServiceSecretsToK8s(&workload.Service, refSlug, projectSecrets, labels)
+	maps.Copy(volumes, secretVolumes)
+	volumeMounts = append(volumeMounts, secretVolumeMounts...)
 
 	livenessProbe, readinessProbe, startupProbe := composeServiceToProbes(&workload.Service)
 	lifecycle := composeServiceToLifecycle(&workload.Service)
 	containerPorts := composeServicePortsToK8sContainerPorts(&workload.Service)
-	resources := composeServiceToResourceRequirements(composeService)
+	resources := composeServiceToResourceRequirements(composeService, emptyDirSize)
 	secret := composeServiceToSecret(&workload.Service, refSlug, labels)
+	secrets := []core.Secret{}
 	envFrom := []core.EnvFromSource{}
 	if secret != nil {
+		secrets = append(secrets, *secret)
 		envFrom = append(envFrom, core.EnvFromSource{SecretRef: &core.SecretEnvSource{LocalObjectReference: core.LocalObjectReference{Name: secret.Name}}})
 	}
+	secrets = append(secrets, fileSecrets...)
 	env := []core.EnvVar{}
 
 	// We need to iterate over the Environment map in an ordered way, because otherwise the resulting secret references will have a non-deterministic order.
@@ -435,10 +744,11 @@ func composeServiceToContainer(
 		ReadinessProbe:  readinessProbe,
 		StartupProbe:    startupProbe,
 		Resources:       resources,
+		SecurityContext: composeServiceToSecurityContext(&workload.Service, targetCfg),
 		Command:         composeService.Entrypoint, // ENTRYPOINT in Docker == 'entrypoint' in Compose == 'command' in K8s
 		Args:            composeService.Command,    // CMD in Docker == 'command' in Compose == 'args' in K8s
 		ImagePullPolicy: core.PullAlways,
-	}, secret, volumes
+	}, secrets, configMaps, volumes
 }
 
 func serviceSpecToService(refSlug string, workload *ir.Service, serviceSpec core.ServiceSpec, labels map[string]string) core.Service {
@@ -446,7 +756,7 @@ func serviceSpecToService(refSlug string, workload *ir.Service, serviceSpec core
 	// We only add the port numbers to the service name if the ports are exposed directly. This is to ensure backwards compatibility with previous versions of k8ify and to keep things neat (not many people will need to expose ports directly).
 	if !serviceSpecIsUnexposedDefault(serviceSpec) {
 		for _, port := range serviceSpec.Ports {
-			serviceName = fmt.Sprintf("%s-%d", serviceName, port.Port)
+			serviceName = fmt.Sprintf("%s-%s", serviceName, port.Name)
 		}
 	}
 	service := core.Service{}
@@ -467,10 +777,44 @@ type PortConfig struct {
 }
 
 func serviceSpecIsUnexposedDefault(serviceSpec core.ServiceSpec) bool {
-	return serviceSpec.Type == "" && serviceSpec.ExternalTrafficPolicy == "" && serviceSpec.HealthCheckNodePort == 0
+	return serviceSpec.Type == "" && serviceSpec.ExternalTrafficPolicy == "" && serviceSpec.HealthCheckNodePort == 0 && serviceSpec.ClusterIP != core.ClusterIPNone
+}
+
+// composeServiceToHeadlessService creates the headless Service of a StatefulSet. It is the governing Service of the
+// StatefulSet (`spec.serviceName`) and provides stable DNS names for the individual pods (e.g. `db-0.db`). It is named
+// like the workload, unless the regular Service already takes that name, then it gets the suffix `-headless`. Since
+// `spec.serviceName` can't be changed on existing StatefulSets, `k8ify.headless.governing: false` keeps the regular
+// Service as the governing Service instead. The label `k8ify.headless` tells the Services apart, e.g. for the
+// ServiceMonitor, which must not scrape the pods twice.
+func composeServiceToHeadlessService(refSlug string, workload *ir.Service, services []core.Service, servicePorts []core.ServicePort, labels map[string]string) (core.Service, bool) {
+	name := workload.Name + refSlug
+	governing := true
+	if slices.ContainsFunc(services, func(s core.Service) bool { return s.Name == name }) {
+		name += "-headless"
+		if value := util.GetOptional(workload.Labels(), "k8ify.headless.governing"); value != nil {
+			governing = util.IsTruthy(*value)
+		}
+	}
+
+	service := core.Service{}
+	service.APIVersion = "v1"
+	service.Kind = "Service"
+	service.Name = name
+	service.Labels = maps.Clone(labels)
+	service.Labels["k8ify.headless"] = "true"
+	service.Annotations = util.Annotations(workload.Labels(), "Service")
+	service.Spec = core.ServiceSpec{
+		ClusterIP:                core.ClusterIPNone,
+		Selector:                 labels,
+		Ports:                    servicePorts,
+		PublishNotReadyAddresses: util.GetBoolean(workload.Labels(), "k8ify.headless.publishNotReadyAddresses"),
+	}
+	return service, governing
 }
 
-func composeServiceToServices(refSlug string, workload *ir.Service, servicePorts []core.ServicePort, labels map[string]string) []core.Service {
+// composeServiceToServices groups the published ports into Services by their configuration (see `k8ify.exposePlain`).
+// Exposed ports are always added to the default ClusterIP Service.
+func composeServiceToServices(refSlug string, workload *ir.Service, servicePorts []core.ServicePort, exposedServicePorts []core.ServicePort, labels map[string]string) []core.Service {
 	var services []core.Service
 	serviceSpecs := map[PortConfig]core.ServiceSpec{}
 
@@ -495,6 +839,15 @@ func composeServiceToServices(refSlug string, workload *ir.Service, servicePorts
 		}
 	}
 
+	if len(exposedServicePorts) > 0 {
+		spec, specExists := serviceSpecs[PortConfig{}]
+		if !specExists {
+			spec = core.ServiceSpec{Selector: labels}
+		}
+		spec.Ports = append(spec.Ports, exposedServicePorts...)
+		serviceSpecs[PortConfig{}] = spec
+	}
+
 	for _, serviceSpec := range serviceSpecs {
 		services = append(services, serviceSpecToService(refSlug, workload, serviceSpec, labels))
 	}
@@ -544,6 +897,13 @@ func composeServiceToServiceMonitors(refSlug string, workload *ir.ParentService,
 					"namespaceSelector": map[string]interface{}{},
 					"selector": map[string]interface{}{
 						"matchLabels": labels,
+						// the headless Service of a StatefulSet selects the same pods
+						"matchExpressions": []interface{}{
+							map[string]interface{}{
+								"key":      "k8ify.headless",
+								"operator": "DoesNotExist",
+							},
+						},
 					},
 				},
 			},
@@ -679,6 +1039,14 @@ func createTlsConfigForEndpoint(secretName string, labels map[string]string) (ma
 	return tlsConfig, secretEntries
 }
 
+// tlsSecretName returns the name of the Secret holding the certificate of `host`, see tlsSecretNames
+func tlsSecretName(host string, defaultName string, secretNames map[string]string) string {
+	if secretName, ok := secretNames[host]; ok {
+		return secretName
+	}
+	return defaultName
+}
+
 func secretKeySelector(secretName string, secretKey string) map[string]interface{} {
 	return map[string]interface{}{
 		"key":  secretKey,
@@ -686,7 +1054,30 @@ func secretKeySelector(secretName string, secretKey string) map[string]interface
 	}
 }
 
-func composeServiceToIngress(workload *ir.ParentService, refSlug string, services []core.Service, labels map[string]string, targetCfg ir.TargetCfg) *networking.Ingress {
+// tlsSecretNames maps each exposed host to the name of the Secret holding its certificate. A host shared by several
+// services uses the Secret of the first of them (by name), so all Ingresses serve the same certificate and only one
+// certificate gets issued for it.
+func tlsSecretNames(ref string, projectServices map[string]*ir.ParentService) map[string]string {
+	secretNames := make(map[string]string)
+	for _, name := range slices.Sorted(maps.Keys(projectServices)) {
+		workload := projectServices[name]
+		refSlug, _ := workloadRefSlugAndLabels(ref, workload)
+		for _, w := range append([]*ir.Service{&workload.Service}, workload.GetParts()...) {
+			// invalid configurations are caught by the prechecks
+			ingressConfigs, _ := w.GetIngressConfigs()
+			for _, ingressConfig := range ingressConfigs {
+				for _, host := range ingressConfig.Hosts {
+					if _, ok := secretNames[host]; !ok {
+						secretNames[host] = workload.Name + refSlug
+					}
+				}
+			}
+		}
+	}
+	return secretNames
+}
+
+func composeServiceToIngress(workload *ir.ParentService, refSlug string, services []core.Service, labels map[string]string, secretNames map[string]string, targetCfg ir.TargetCfg) *networking.Ingress {
 	var service *core.Service
 	for _, s := range services {
 		if serviceSpecIsUnexposedDefault(s.Spec) {
@@ -701,65 +1092,59 @@ func composeServiceToIngress(workload *ir.ParentService, refSlug string, service
 	workloads = append(workloads, workload.GetParts()...)
 
 	var ingressRules []networking.IngressRule
-	var ingressTLSs []networking.IngressTLS
+	// all hosts sharing a certificate go into one TLS entry, the apps domain hosts use the empty secret name
+	var tlsSecrets []string
+	tlsHosts := make(map[string][]string)
 
 	for _, w := range workloads {
-		for i, port := range w.GetPorts() {
-			// we expect the config to be in "k8ify.expose.PORT"
-			configPrefix := fmt.Sprintf("k8ify.expose.%d", port.ServicePort)
-			ingressConfig := util.SubConfig(w.Labels(), configPrefix, "host")
-			if _, ok := ingressConfig["host"]; !ok && i == 0 {
-				// for the first port we also accept config in "k8ify.expose"
-				ingressConfig = util.SubConfig(w.Labels(), "k8ify.expose", "host")
+		// invalid configurations are caught by the prechecks
+		ingressConfigs, _ := w.GetIngressConfigs()
+		for _, ingressConfig := range ingressConfigs {
+			pathType := networking.PathType(ingressConfig.PathType)
+			path := networking.HTTPIngressPath{
+				PathType: &pathType,
+				Path:     ingressConfig.Path,
+				Backend: networking.IngressBackend{
+					Service: &networking.IngressServiceBackend{
+						Name: service.Name,
+						Port: networking.ServiceBackendPort{
+							Number: int32(ingressConfig.Port),
+						},
+					},
+				},
 			}
 
-			if host, ok := ingressConfig["host"]; ok {
-				serviceBackendPort := networking.ServiceBackendPort{
-					Number: int32(port.ServicePort),
-				}
-
-				ingressServiceBackend := networking.IngressServiceBackend{
-					Name: service.Name,
-					Port: serviceBackendPort,
-				}
-
-				ingressBackend := networking.IngressBackend{
-					Service: &ingressServiceBackend,
-				}
-
-				pathType := networking.PathTypePrefix
-				path := networking.HTTPIngressPath{
-					PathType: &pathType,
-					Path:     "/",
-					Backend:  ingressBackend,
-				}
+			for _, host := range ingressConfig.Hosts {
+				ingressRules = appendIngressPath(ingressRules, host, path)
 
-				httpIngressRuleValue := networking.HTTPIngressRuleValue{
-					Paths: []networking.HTTPIngressPath{path},
+				secretName := ""
+				if !targetCfg.IsSubdomainOfAppsDomain(host) {
+					// special case: With an empty TLS configuration the ingress uses the cluster-wide apps domain wildcard certificate
+					secretName = tlsSecretName(host, workload.Name+refSlug, secretNames)
 				}
-
-				ingressRuleValue := networking.IngressRuleValue{
-					HTTP: &httpIngressRuleValue,
+				if _, ok := tlsHosts[secretName]; !ok {
+					tlsSecrets = append(tlsSecrets, secretName)
+					tlsHosts[secretName] = []string{}
 				}
-
-				ingressRules = append(ingressRules, networking.IngressRule{
-					Host:             host,
-					IngressRuleValue: ingressRuleValue,
-				})
-
-				if targetCfg.IsSubdomainOfAppsDomain(host) {
-					// special case: With an empty TLS configuration the ingress uses the cluster-wide apps domain wildcard certificate
-					ingressTLSs = append(ingressTLSs, networking.IngressTLS{})
-				} else {
-					ingressTLSs = append(ingressTLSs, networking.IngressTLS{
-						Hosts:      []string{host},
-						SecretName: workload.Name + refSlug,
-					})
+				if secretName != "" && !slices.Contains(tlsHosts[secretName], host) {
+					tlsHosts[secretName] = append(tlsHosts[secretName], host)
 				}
 			}
 		}
 	}
 
+	var ingressTLSs []networking.IngressTLS
+	for _, secretName := range tlsSecrets {
+		if secretName == "" {
+			ingressTLSs = append(ingressTLSs, networking.IngressTLS{})
+		} else {
+			ingressTLSs = append(ingressTLSs, networking.IngressTLS{
+				Hosts:      tlsHosts[secretName],
+				SecretName: secretName,
+			})
+		}
+	}
+
 	if len(ingressRules) == 0 {
 		return nil
 	}
@@ -770,14 +1155,40 @@ func composeServiceToIngress(workload *ir.ParentService, refSlug string, service
 	ingress.Name = workload.Name + refSlug
 	ingress.Labels = labels
 	ingress.Annotations = util.Annotations(workload.Labels(), "Ingress")
+	for key, value := range certManagerIngressAnnotations(ingressTLSs, targetCfg) {
+		if _, ok := ingress.Annotations[key]; !ok {
+			ingress.Annotations[key] = value
+		}
+	}
 	ingress.Spec = networking.IngressSpec{
 		Rules: ingressRules,
 		TLS:   ingressTLSs,
 	}
+	if ingressClassName := targetCfg.IngressClassName(); ingressClassName != "" {
+		ingress.Spec.IngressClassName = &ingressClassName
+	}
 
 	return &ingress
 }
 
+// appendIngressPath adds the path to the rule of the host, all paths of a host are combined into a single rule
+func appendIngressPath(ingressRules []networking.IngressRule, host string, path networking.HTTPIngressPath) []networking.IngressRule {
+	for _, ingressRule := range ingressRules {
+		if ingressRule.Host == host {
+			ingressRule.HTTP.Paths = append(ingressRule.HTTP.Paths, path)
+			return ingressRules
+		}
+	}
+	return append(ingressRules, networking.IngressRule{
+		Host: host,
+		IngressRuleValue: networking.IngressRuleValue{
+			HTTP: &networking.HTTPIngressRuleValue{
+				Paths: []networking.HTTPIngressPath{path},
+			},
+		},
+	})
+}
+
 func composeServiceToProbe(config map[string]string, port intstr.IntOrString) *core.Probe {
 	if enabledStr, ok := config["enabled"]; ok {
 		if !util.IsTruthy(enabledStr) {
@@ -828,14 +1239,28 @@ func composeServiceToProbe(config map[string]string, port intstr.IntOrString) *c
 
 func composeServiceToProbes(workload *ir.Service) (*core.Probe, *core.Probe, *core.Probe) {
 	composeService := workload.AsCompose()
-	if len(composeService.Ports) == 0 {
-		return nil, nil, nil
-	}
-	port := intstr.IntOrString{IntVal: int32(composeService.Ports[0].Target)}
 	livenessConfig := util.SubConfig(composeService.Labels, "k8ify.liveness", "path")
 	readinessConfig := util.SubConfig(composeService.Labels, "k8ify.readiness", "path")
 	startupConfig := util.SubConfig(composeService.Labels, "k8ify.startup", "path")
 
+	// The Compose healthcheck is only used if the probes are not configured explicitly via labels
+	if len(livenessConfig) == 0 && len(readinessConfig) == 0 && len(startupConfig) == 0 && hasHealthCheck(composeService) {
+		livenessProbe, startupProbe := composeHealthCheckToProbes(composeService.HealthCheck)
+		return livenessProbe, nil, startupProbe
+	}
+
+	// exposed ports are only used if there is no published port
+	tcpPorts := []ir.PublishedPort{}
+	for _, port := range append(workload.GetPorts(), workload.GetExposedPorts()...) {
+		if port.IsTCP() {
+			tcpPorts = append(tcpPorts, port)
+		}
+	}
+	if len(tcpPorts) == 0 {
+		return nil, nil, nil
+	}
+	port := intstr.IntOrString{IntVal: int32(tcpPorts[0].ContainerPort)}
+
 	// Protect application from overly eager livenessProbe during startup while keeping the startup fast.
 	// By default the startupProbe is the same as the livenessProbe except for periodSeconds and failureThreshold
 	for k, v := range livenessConfig {
@@ -881,7 +1306,9 @@ func composeServiceToLifecycle(workload *ir.Service) *core.Lifecycle {
 	}
 }
 
-func composeServiceToResourceRequirements(composeService composeTypes.ServiceConfig) core.ResourceRequirements {
+// composeServiceToResourceRequirements converts the reservations and limits of the service. `memoryBackedVolumeSize` is
+// added to the memory reservation and limit, if there are any, to make room for the content of memory-backed volumes.
+func composeServiceToResourceRequirements(composeService composeTypes.ServiceConfig, memoryBackedVolumeSize int64) core.ResourceRequirements {
 	requestsMap := core.ResourceList{}
 	limitsMap := core.ResourceList{}
 
@@ -893,8 +1320,9 @@ func composeServiceToResourceRequirements(composeService composeTypes.ServiceCon
 				requestsMap["cpu"] = resource.MustParse(fmt.Sprintf("%f", cpuRequest))
 				limitsMap["cpu"] = resource.MustParse(fmt.Sprintf("%f", cpuRequest*10.0))
 			}
-			memRequest := composeService.Deploy.Resources.Reservations.MemoryBytes
+			memRequest := int64(composeService.Deploy.Resources.Reservations.MemoryBytes)
 			if memRequest > 0 {
+				memRequest += memoryBackedVolumeSize
 				requestsMap["memory"] = resource.MustParse(fmt.Sprintf("%dMi", memRequest/1024/1024))
 				limitsMap["memory"] = resource.MustParse(fmt.Sprintf("%dMi", memRequest/1024/1024))
 			}
@@ -906,8 +1334,9 @@ func composeServiceToResourceRequirements(composeService composeTypes.ServiceCon
 			if cpuLimit > 0 {
 				limitsMap["cpu"] = resource.MustParse(fmt.Sprintf("%f", cpuLimit))
 			}
-			memLimit := composeService.Deploy.Resources.Limits.MemoryBytes
+			memLimit := int64(composeService.Deploy.Resources.Limits.MemoryBytes)
 			if memLimit > 0 {
+				memLimit += memoryBackedVolumeSize
 				limitsMap["memory"] = resource.MustParse(fmt.Sprintf("%dMi", memLimit/1024/1024))
 			}
 		}
@@ -956,7 +1385,9 @@ func CallExternalConverter(resourceName string, options map[string]string) (unst
 	return otherResource, nil
 }
 
-func ComposeServiceToK8s(ref string, workload *ir.ParentService, projectVolumes map[string]*ir.Volume, targetCfg ir.TargetCfg) Objects {
+// workloadRefSlugAndLabels returns the suffix for the names of all resources of a workload (e.g. "-myref", or "" for
+// singletons) along with the labels identifying them
+func workloadRefSlugAndLabels(ref string, workload *ir.ParentService) (string, map[string]string) {
 	refSlug := toRefSlug(util.SanitizeWithMinLength(ref, 4), workload)
 	labels := make(map[string]string)
 	labels["k8ify.service"] = workload.Name
@@ -964,6 +1395,11 @@ func ComposeServiceToK8s(ref string, workload *ir.ParentService, projectVolumes
 		labels["k8ify.ref-slug"] = refSlug
 		refSlug = "-" + refSlug
 	}
+	return refSlug, labels
+}
+
+func ComposeServiceToK8s(ref string, workload *ir.ParentService, projectServices map[string]*ir.ParentService, projectVolumes map[string]*ir.Volume, projectConfigs map[string]*ir.FileObject, projectSecrets map[string]*ir.FileObject, targetCfg ir.TargetCfg) Objects {
+	refSlug, labels := workloadRefSlugAndLabels(ref, workload)
 
 	objects := Objects{}
 
@@ -992,11 +1428,13 @@ func ComposeServiceToK8s(ref string, workload *ir.ParentService, projectVolumes
 	}
 
 	servicePorts := composeServicePortsToK8sServicePorts(workload)
-	objects.Services = composeServiceToServices(refSlug, &workload.Service, servicePorts, labels)
+	exposedServicePorts := composeServiceExposedPortsToK8sServicePorts(workload)
+	objects.Services = composeServiceToServices(refSlug, &workload.Service, servicePorts, exposedServicePorts, labels)
 	objects.CiliumNetworkPolicies = append(objects.CiliumNetworkPolicies, networkpolicy.CreateNetworkPoliciesForExposedPorts(targetCfg, refSlug, workload, labels, servicePorts)...)
-	serviceMonitors, serviceMonitorSecrets := composeServiceToServiceMonitors(refSlug, workload, servicePorts, labels)
+	serviceMonitors, serviceMonitorSecrets := composeServiceToServiceMonitors(refSlug, workload, append(servicePorts, exposedServicePorts...), labels)
 	objects.ServiceMonitors = serviceMonitors
 	objects.Secrets = append(objects.Secrets, serviceMonitorSecrets...)
+	objects.Certificates = composeServiceToCertificate(workload, refSlug, labels, targetCfg)
 
 	// Find volumes used by this service and all its parts
 	rwoVolumes, rwxVolumes := workload.Volumes(projectVolumes)
@@ -1010,54 +1448,136 @@ func ComposeServiceToK8s(ref string, workload *ir.ParentService, projectVolumes
 	// turned into PersistentVolumeClaims. Note that since these volumes are shared, the same PersistentVolumeClaim might
 	// be generated by multiple compose services. Objects.Append() takes care of deduplication.
 	pvcs := []core.PersistentVolumeClaim{}
-	for _, vol := range rwxVolumes {
-		pvcs = append(pvcs, ComposeSharedVolumeToK8s(ref, vol))
+	for _, name := range slices.Sorted(maps.Keys(rwxVolumes)) {
+		vol := rwxVolumes[name]
+		pvc := ComposeSharedVolumeToK8s(ref, vol)
+		pvcs = append(pvcs, pvc)
+		schedules, secrets := composeVolumeToBackup(pvc.Name, pvc.Labels, pvc.Labels, vol, targetCfg)
+		objects.BackupSchedules = append(objects.BackupSchedules, schedules...)
+		objects.Secrets = append(objects.Secrets, secrets...)
 	}
 	objects.PersistentVolumeClaims = pvcs
 
-	if len(rwoVolumes) > 0 {
+	initContainers := composeServiceToInitContainers(ref, workload, projectServices, targetCfg)
+	jobConfig, _ := ir.JobConfigPointer(workload.Labels())
+	var workloadKind string
+	if jobConfig != nil && jobConfig.Kind == "CronJob" {
+		cronJob, secrets, configMaps := composeServiceToCronJob(workload, refSlug, projectVolumes, projectConfigs, projectSecrets, labels, targetCfg, jobConfig)
+		cronJob.Spec.JobTemplate.Spec.Template.Spec.InitContainers = initContainers
+		objects.CronJobs = []batch.CronJob{cronJob}
+		objects.Secrets = append(objects.Secrets, secrets...)
+		objects.ConfigMaps = configMaps
+		workloadKind = cronJob.Kind
+	} else if jobConfig != nil {
+		job, secrets, configMaps := composeServiceToJob(workload, refSlug, projectVolumes, projectConfigs, projectSecrets, labels, targetCfg, jobConfig)
+		job.Spec.Template.Spec.InitContainers = initContainers
+		objects.Jobs = []batch.Job{job}
+		objects.Secrets = append(objects.Secrets, secrets...)
+		objects.ConfigMaps = configMaps
+		workloadKind = job.Kind
+	} else if workload.IsGlobal() {
+		daemonSet, secrets, configMaps := composeServiceToDaemonSet(workload, refSlug, projectVolumes, projectConfigs, projectSecrets, labels, targetCfg)
+		daemonSet.Spec.Template.Spec.InitContainers = initContainers
+		objects.DaemonSets = []apps.DaemonSet{daemonSet}
+		objects.Secrets = append(objects.Secrets, secrets...)
+		objects.ConfigMaps = configMaps
+		workloadKind = daemonSet.Kind
+	} else if len(rwoVolumes) > 0 {
 		// rwo volumes mean that we can only have one instance of the service, hence StatefulSet is the right choice.
 		// Technically we might have multiple instances with a StatefulSet but then every instance gets its own volume,
 		// ensuring that each volume remains rwo
 		pvcs := []core.PersistentVolumeClaim{}
-		for _, vol := range rwoVolumes {
-			pvcs = append(pvcs, composeVolumeToPvc(vol.Name, labels, vol))
+		// sorted to keep the order stable, the volume claim templates of a StatefulSet are immutable
+		for _, name := range slices.Sorted(maps.Keys(rwoVolumes)) {
+			vol := rwoVolumes[name]
+			pvc := composeVolumeToPvc(vol.Name, labels, vol)
+			pvc.Spec.DataSource = composeVolumeToDataSource(ref, vol, func(cloneRefSlug string) string {
+				// the PersistentVolumeClaim of the first pod of the StatefulSet in the other ref
+				return vol.Name + "-" + workload.Name + "-" + cloneRefSlug + "-0"
+			})
+			if backupConfig, _ := ir.BackupConfigPointer(vol.Labels()); backupConfig != nil {
+				// All volume claim templates share the labels of the StatefulSet, the backup Schedule needs one more
+				// to select the PersistentVolumeClaims of this volume only
+				pvc.Labels = maps.Clone(labels)
+				pvc.Labels["k8ify.volume"] = vol.Name
+			}
+			pvcs = append(pvcs, pvc)
+			schedules, secrets := composeVolumeToBackup(vol.Name+"-"+workload.Name+refSlug, labels, pvc.Labels, vol, targetCfg)
+			objects.BackupSchedules = append(objects.BackupSchedules, schedules...)
+			objects.Secrets = append(objects.Secrets, secrets...)
 		}
 
-		statefulset, secrets := composeServiceToStatefulSet(
+		statefulset, secrets, configMaps := composeServiceToStatefulSet(
 			workload,
 			refSlug,
 			projectVolumes,
+			projectConfigs,
+			projectSecrets,
 			pvcs,
 			labels,
+			targetCfg,
 		)
+		statefulset.Spec.Template.Spec.InitContainers = initContainers
+		headlessService, governing := composeServiceToHeadlessService(refSlug, &workload.Service, objects.Services, append(servicePorts, exposedServicePorts...), labels)
+		if governing {
+			statefulset.Spec.ServiceName = headlessService.Name
+		}
 		objects.StatefulSets = []apps.StatefulSet{statefulset}
+		objects.Services = append(objects.Services, headlessService)
 		objects.Secrets = append(objects.Secrets, secrets...)
+		objects.ConfigMaps = configMaps
+		workloadKind = statefulset.Kind
 	} else {
-		deployment, secrets := composeServiceToDeployment(
+		deployment, secrets, configMaps := composeServiceToDeployment(
 			workload,
 			refSlug,
 			projectVolumes,
+			projectConfigs,
+			projectSecrets,
 			labels,
+			targetCfg,
 		)
+		deployment.Spec.Template.Spec.InitContainers = initContainers
 		objects.Deployments = []apps.Deployment{deployment}
 		objects.Secrets = append(objects.Secrets, secrets...)
+		objects.ConfigMaps = configMaps
+		workloadKind = deployment.Kind
+	}
+
+	horizontalPodAutoscaler := composeServiceToHorizontalPodAutoscaler(&workload.Service, refSlug, workloadKind, labels)
+	if horizontalPodAutoscaler == nil || jobConfig != nil || workload.IsGlobal() {
+		objects.HorizontalPodAutoscalers = []autoscaling.HorizontalPodAutoscaler{}
+	} else {
+		objects.HorizontalPodAutoscalers = []autoscaling.HorizontalPodAutoscaler{*horizontalPodAutoscaler}
 	}
 
 	podDisruptionBudget := composeServiceToPodDisruptionBudget(&workload.Service, refSlug, labels)
-	if podDisruptionBudget == nil {
+	if podDisruptionBudget == nil || jobConfig != nil || workload.IsGlobal() {
 		objects.PodDisruptionBudgets = []v1.PodDisruptionBudget{}
 	} else {
 		objects.PodDisruptionBudgets = []v1.PodDisruptionBudget{*podDisruptionBudget}
 	}
 
-	ingress := composeServiceToIngress(workload, refSlug, objects.Services, labels, targetCfg)
-	if ingress == nil {
-		objects.Ingresses = []networking.Ingress{}
-	} else {
-		objects.Ingresses = []networking.Ingress{*ingress}
+	objects.Ingresses = []networking.Ingress{}
+	objects.Routes = []unstructured.Unstructured{}
+	switch targetCfg.ExposeScheme() {
+	case ir.ExposeSchemeGatewayAPI:
+		objects.Routes = composeServiceToRoutes(workload, refSlug, objects.Services, labels, targetCfg)
+	case ir.ExposeSchemeOpenShiftRoute:
+		routes, certificates := composeServiceToOpenShiftRoutes(workload, refSlug, objects.Services, labels, tlsSecretNames(ref, projectServices), targetCfg)
+		objects.Routes = routes
+		objects.Certificates = append(objects.Certificates, certificates...)
+	default:
+		if ingress := composeServiceToIngress(workload, refSlug, objects.Services, labels, tlsSecretNames(ref, projectServices), targetCfg); ingress != nil {
+			secrets, others := composeServiceToIngressFeatures(workload, ingress, objects.Services, labels, targetCfg)
+			objects.Ingresses = []networking.Ingress{*ingress}
+			objects.Secrets = append(objects.Secrets, secrets...)
+			objects.Others = append(objects.Others, others...)
+		}
 	}
 
+	objects.NetworkPolicies = networkpolicy.CreateNetworkPolicies(targetCfg, refSlug, workload, labels, projectServices, objects.Services, objects.Ingresses, objects.Routes, objects.ServiceMonitors)
+
 	return objects
 }
 
@@ -1071,16 +1591,48 @@ func ComposeSharedVolumeToK8s(ref string, volume *ir.Volume) core.PersistentVolu
 	}
 	name := volume.Name + refSlug
 	pvc := composeVolumeToPvc(name, labels, volume)
+	pvc.Spec.DataSource = composeVolumeToDataSource(ref, volume, func(cloneRefSlug string) string {
+		return volume.Name + "-" + cloneRefSlug
+	})
 
 	return pvc
 }
 
+// composeVolumeToDataSource returns the PersistentVolumeClaim or VolumeSnapshot of the ref named by `k8ify.cloneFromRef`
+// a new PersistentVolumeClaim is cloned from. `claimName` returns the name of the PersistentVolumeClaim of the volume in
+// the ref with the given ref slug, a VolumeSnapshot is expected to have the same name. Returns nil if the volume isn't
+// cloned, is a singleton (which is the same in all refs) or belongs to the ref to clone from.
+func composeVolumeToDataSource(ref string, volume *ir.Volume, claimName func(cloneRefSlug string) string) *core.TypedLocalObjectReference {
+	cloneConfig, _ := ir.CloneConfigPointer(volume.Labels())
+	if cloneConfig == nil || volume.IsSingleton() {
+		return nil
+	}
+	cloneRefSlug := util.SanitizeWithMinLength(cloneConfig.Ref, 4)
+	if cloneRefSlug == util.SanitizeWithMinLength(ref, 4) {
+		return nil
+	}
+
+	dataSource := core.TypedLocalObjectReference{
+		Kind: cloneConfig.Kind,
+		Name: util.Sanitize(claimName(cloneRefSlug)),
+	}
+	if cloneConfig.Kind == "VolumeSnapshot" {
+		dataSource.APIGroup = util.GetPointer("snapshot.storage.k8s.io")
+	}
+	return &dataSource
+}
+
 func composeVolumeToPvc(name string, labels map[string]string, volume *ir.Volume) core.PersistentVolumeClaim {
 	name = util.Sanitize(name)
 	accessMode := core.ReadWriteOnce
 	if volume.IsShared() {
 		accessMode = core.ReadWriteMany
 	}
+	var annotations map[string]string
+	if backupConfig, _ := ir.BackupConfigPointer(volume.Labels()); backupConfig != nil {
+		// K8up only backs up ReadWriteOnce volumes if they are annotated explicitly
+		annotations = map[string]string{"k8up.io/backup": "true"}
+	}
 
 	return core.PersistentVolumeClaim{
 		TypeMeta: metav1.TypeMeta{
@@ -1088,8 +1640,9 @@ func composeVolumeToPvc(name string, labels map[string]string, volume *ir.Volume
 			Kind:       "PersistentVolumeClaim",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   name,
-			Labels: labels,
+			Name:        name,
+			Labels:      labels,
+			Annotations: annotations,
 		},
 		Spec: core.PersistentVolumeClaimSpec{
 			AccessModes: []core.PersistentVolumeAccessMode{accessMode},
@@ -1105,6 +1658,11 @@ func composeVolumeToPvc(name string, labels map[string]string, volume *ir.Volume
 
 func composeServiceToPodDisruptionBudget(workload *ir.Service, refSlug string, labels map[string]string) *v1.PodDisruptionBudget {
 	replicas := composeServiceToReplicas(workload.AsCompose())
+	autoscaleConfig, _ := ir.AutoscaleConfigPointer(workload.Labels())
+	if autoscaleConfig != nil {
+		// the HorizontalPodAutoscaler never scales below its minimum, which is what the budget has to protect
+		replicas = &autoscaleConfig.Min
+	}
 	if replicas == nil || *replicas <= 1 {
 		return nil
 	}
@@ -1128,16 +1686,25 @@ func composeServiceToPodDisruptionBudget(workload *ir.Service, refSlug string, l
 // Objects combines all possible resources the conversion process could produce
 type Objects struct {
 	// Deployments
-	CiliumNetworkPolicies  []unstructured.Unstructured
-	Deployments            []apps.Deployment
-	StatefulSets           []apps.StatefulSet
-	Services               []core.Service
-	PersistentVolumeClaims []core.PersistentVolumeClaim
-	Secrets                []core.Secret // You don't have to create secrets for all values. A reference is also possible with _ref_ and _secretRef_.
-	ServiceMonitors        []unstructured.Unstructured
-	Ingresses              []networking.Ingress
-	PodDisruptionBudgets   []v1.PodDisruptionBudget
-	Others                 []unstructured.Unstructured
+	CiliumNetworkPolicies    []unstructured.Unstructured
+	Deployments              []apps.Deployment
+	StatefulSets             []apps.StatefulSet
+	DaemonSets               []apps.DaemonSet
+	Jobs                     []batch.Job
+	CronJobs                 []batch.CronJob
+	Services                 []core.Service
+	PersistentVolumeClaims   []core.PersistentVolumeClaim
+	Secrets                  []core.Secret // You don't have to create secrets for all values. A reference is also possible with _ref_ and _secretRef_.
+	ConfigMaps               []core.ConfigMap
+	ServiceMonitors          []unstructured.Unstructured
+	BackupSchedules          []unstructured.Unstructured
+	Certificates             []unstructured.Unstructured
+	Ingresses                []networking.Ingress
+	Routes                   []unstructured.Unstructured // Gateway API or OpenShift routes
+	NetworkPolicies          []networking.NetworkPolicy
+	PodDisruptionBudgets     []v1.PodDisruptionBudget
+	HorizontalPodAutoscalers []autoscaling.HorizontalPodAutoscaler
+	Others                   []unstructured.Unstructured
 }
 
 func (o Objects) Append(other Objects) Objects {
@@ -1154,16 +1721,64 @@ func (o Objects) Append(other Objects) Objects {
 		}
 	}
 
+	// Merge NetworkPolicies while avoiding duplicates based on the name, the default-deny NetworkPolicy is generated
+	// for every service of a ref
+	networkPolicies := o.NetworkPolicies
+	networkPolicyNameSet := make(map[string]bool)
+	for _, networkPolicy := range networkPolicies {
+		networkPolicyNameSet[networkPolicy.Name] = true
+	}
+	for _, networkPolicy := range other.NetworkPolicies {
+		if !networkPolicyNameSet[networkPolicy.Name] {
+			networkPolicies = append(networkPolicies, networkPolicy)
+			networkPolicyNameSet[networkPolicy.Name] = true
+		}
+	}
+
+	// Merge Secrets and backup Schedules while avoiding duplicates based on the name, the Secret holding the password
+	// of the backup repository is generated for every volume that is backed up
+	secrets := o.Secrets
+	secretNameSet := make(map[string]bool)
+	for _, secret := range secrets {
+		secretNameSet[secret.Name] = true
+	}
+	for _, secret := range other.Secrets {
+		if !secretNameSet[secret.Name] {
+			secrets = append(secrets, secret)
+			secretNameSet[secret.Name] = true
+		}
+	}
+	backupSchedules := o.BackupSchedules
+	backupScheduleNameSet := make(map[string]bool)
+	for _, backupSchedule := range backupSchedules {
+		backupScheduleNameSet[backupSchedule.GetName()] = true
+	}
+	for _, backupSchedule := range other.BackupSchedules {
+		if !backupScheduleNameSet[backupSchedule.GetName()] {
+			backupSchedules = append(backupSchedules, backupSchedule)
+			backupScheduleNameSet[backupSchedule.GetName()] = true
+		}
+	}
+
 	return Objects{
-		CiliumNetworkPolicies:  append(o.CiliumNetworkPolicies, other.CiliumNetworkPolicies...),
-		Deployments:            append(o.Deployments, other.Deployments...),
-		StatefulSets:           append(o.StatefulSets, other.StatefulSets...),
-		Services:               append(o.Services, other.Services...),
-		ServiceMonitors:        append(o.ServiceMonitors, other.ServiceMonitors...),
-		PersistentVolumeClaims: pvcs,
-		Secrets:                append(o.Secrets, other.Secrets...),
-		Ingresses:              append(o.Ingresses, other.Ingresses...),
-		PodDisruptionBudgets:   append(o.PodDisruptionBudgets, other.PodDisruptionBudgets...),
-		Others:                 append(o.Others, other.Others...),
+		CiliumNetworkPolicies:    append(o.CiliumNetworkPolicies, other.CiliumNetworkPolicies...),
+		Deployments:              append(o.Deployments, other.Deployments...),
+		StatefulSets:             append(o.StatefulSets, other.StatefulSets...),
+		DaemonSets:               append(o.DaemonSets, other.DaemonSets...),
+		Jobs:                     append(o.Jobs, other.Jobs...),
+		CronJobs:                 append(o.CronJobs, other.CronJobs...),
+		Services:                 append(o.Services, other.Services...),
+		ServiceMonitors:          append(o.ServiceMonitors, other.ServiceMonitors...),
+		BackupSchedules:          backupSchedules,
+		Certificates:             append(o.Certificates, other.Certificates...),
+		PersistentVolumeClaims:   pvcs,
+		Secrets:                  secrets,
+		ConfigMaps:               append(o.ConfigMaps, other.ConfigMaps...),
+		Ingresses:                append(o.Ingresses, other.Ingresses...),
+		Routes:                   append(o.Routes, other.Routes...),
+		NetworkPolicies:          networkPolicies,
+		PodDisruptionBudgets:     append(o.PodDisruptionBudgets, other.PodDisruptionBudgets...),
+		HorizontalPodAutoscalers: append(o.HorizontalPodAutoscalers, other.HorizontalPodAutoscalers...),
+		Others:                   append(o.Others, other.Others...),
 	}
 }
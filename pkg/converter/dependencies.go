@@ -0,0 +1,123 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+
+	composeTypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/sirupsen/logrus"
+	"github.com/vshn/k8ify/pkg/ir"
+	"github.com/vshn/k8ify/pkg/util"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// composeServiceToInitContainers creates an init container for each dependency (`depends_on`) of the service and its
+// parts. Each of them waits until the K8s Service of the dependency accepts connections on its first TCP port. Since K8s
+// Services only forward to ready pods, this also covers `condition: service_healthy`.
+func composeServiceToInitContainers(ref string, workload *ir.ParentService, projectServices map[string]*ir.ParentService, targetCfg ir.TargetCfg) []core.Container {
+	dependencies := make(map[string]composeTypes.ServiceDependency)
+	for _, service := range append([]*ir.Service{&workload.Service}, workload.GetParts()...) {
+		for name, dependency := range service.AsCompose().DependsOn {
+			dependencies[name] = dependency
+		}
+	}
+	names := make([]string, 0, len(dependencies))
+	for name := range dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	initContainers := []core.Container{}
+	for _, name := range names {
+		if dependencies[name].Condition == composeTypes.ServiceConditionCompletedSuccessfully {
+			logrus.Warnf("Service '%s': Ignoring dependency on '%s', waiting for a service to complete is not supported", workload.Name, name)
+			continue
+		}
+		dependency, dependencyPart := findDependency(name, projectServices)
+		if dependency == nil || dependency.Name == workload.Name {
+			// parts of the same pod are started together anyway
+			continue
+		}
+		host, port, ok := dependencyServiceAddress(ref, dependency, dependencyPart)
+		if !ok {
+			logrus.Warnf("Service '%s': Ignoring dependency on '%s', it has no ports to wait for", workload.Name, name)
+			continue
+		}
+		initContainer := waitForDependencyContainer(name, host, port, targetCfg)
+		initContainer.SecurityContext = initContainerSecurityContext(&workload.Service, targetCfg)
+		initContainers = append(initContainers, initContainer)
+	}
+	return initContainers
+}
+
+// findDependency returns the parent service of the Compose service `name`, and the part itself if `name` is a part
+func findDependency(name string, projectServices map[string]*ir.ParentService) (*ir.ParentService, *ir.Service) {
+	if service, ok := projectServices[name]; ok {
+		return service, &service.Service
+	}
+	for _, service := range projectServices {
+		for _, part := range service.GetParts() {
+			if part.Name == name {
+				return service, part
+			}
+		}
+	}
+	return nil, nil
+}
+
+// dependencyServiceAddress returns the name and port of the K8s Service providing the first TCP port of a dependency,
+// published ports take precedence over exposed ones. If the dependency is a part without TCP ports of its own, the first
+// TCP port of its pod is used instead.
+func dependencyServiceAddress(ref string, dependency *ir.ParentService, dependencyPart *ir.Service) (string, int32, bool) {
+	servicePorts := composeServicePortsToK8sServicePorts(dependency)
+	exposedServicePorts := composeServiceExposedPortsToK8sServicePorts(dependency)
+	port := int32(0)
+	for _, servicePort := range append(servicePorts, exposedServicePorts...) {
+		if servicePort.Protocol == "" {
+			port = servicePort.Port
+			break
+		}
+	}
+	if port == 0 {
+		return "", 0, false
+	}
+	for _, partPort := range append(dependencyPart.GetPorts(), dependencyPart.GetExposedPorts()...) {
+		if partPort.IsTCP() {
+			port = int32(partPort.ServicePort)
+			break
+		}
+	}
+	refSlug, labels := workloadRefSlugAndLabels(ref, dependency)
+	services := composeServiceToServices(refSlug, &dependency.Service, servicePorts, exposedServicePorts, labels)
+	for _, service := range services {
+		for _, servicePort := range service.Spec.Ports {
+			if servicePort.Port == port && servicePort.Protocol == "" {
+				return service.Name, servicePort.Port, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+func waitForDependencyContainer(name string, host string, port int32, targetCfg ir.TargetCfg) core.Container {
+	script := fmt.Sprintf("until nc -z -w 2 %[1]s %[2]d; do echo 'Waiting for %[1]s:%[2]d'; sleep 2; done", host, port)
+	command := []string{"sh", "-c", script}
+	if timeout := targetCfg.WaitForDependenciesTimeout(); timeout > 0 {
+		command = append([]string{"timeout", fmt.Sprint(timeout)}, command...)
+	}
+	return core.Container{
+		Name:    "wait-for-" + util.Sanitize(name),
+		Image:   targetCfg.WaitForDependenciesImage(),
+		Command: command,
+		Resources: core.ResourceRequirements{
+			Requests: core.ResourceList{
+				core.ResourceCPU:    resource.MustParse("10m"),
+				core.ResourceMemory: resource.MustParse("16Mi"),
+			},
+			Limits: core.ResourceList{
+				core.ResourceMemory: resource.MustParse("16Mi"),
+			},
+		},
+	}
+}
@@ -0,0 +1,311 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"unicode/utf8"
+
+	composeTypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/sirupsen/logrus"
+	"github.com/vshn/k8ify/pkg/ir"
+	"github.com/vshn/k8ify/pkg/util"
+	core "k8s.io/api/core/v1"
+)
+
+// configMapKeyRegexp matches the file names that can be used as keys of a ConfigMap
+var configMapKeyRegexp = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// maxConfigMapSize is the maximum size of the data of a ConfigMap accepted by K8s
+const maxConfigMapSize = 1024 * 1024
+
+// bindAsConfigMap returns true if the bind mounts of the service should be converted into ConfigMaps. The label of the
+// service takes precedence over the target cluster configuration.
+func bindAsConfigMap(workload *ir.Service, targetCfg ir.TargetCfg) bool {
+	if value := util.GetOptional(workload.Labels(), "k8ify.bindAsConfigMap"); value != nil {
+		return util.IsTruthy(*value)
+	}
+	return targetCfg.BindAsConfigMap()
+}
+
+// composeServiceBindMountsToK8s converts bind mounts of files and directories into ConfigMaps, which are mounted
+// read-only at the same target. The content is read at conversion time, directories are converted non-recursively.
+func composeServiceBindMountsToK8s(
+	workload *ir.Service,
+	refSlug string,
+	labels map[string]string,
+	targetCfg ir.TargetCfg,
+) (map[string]core.Volume, []core.VolumeMount, []core.ConfigMap) {
+	volumes := make(map[string]core.Volume)
+	volumeMounts := []core.VolumeMount{}
+	configMaps := []core.ConfigMap{}
+
+	enabled := bindAsConfigMap(workload, targetCfg)
+	for _, mount := range workload.AsCompose().Volumes {
+		if mount.Type != composeTypes.VolumeTypeBind {
+			continue
+		}
+		if !enabled {
+			logrus.Warnf("Service '%s': Ignoring bind mount of '%s'. Set the label 'k8ify.bindAsConfigMap: true' to convert it into a ConfigMap.", workload.Name, mount.Source)
+			continue
+		}
+
+		data, isDir, err := readBindMountSource(mount.Source)
+		if err != nil {
+			logrus.Warnf("Service '%s': Ignoring bind mount of '%s', it can't be converted into a ConfigMap: %s", workload.Name, mount.Source, err)
+			continue
+		}
+
+		// MinLength of 3 for consistency with the names of tmpfs volumes
+		suffix := util.SanitizeWithMinLength(mount.Target, 3)
+		volumeName := fmt.Sprintf("%s-bind-%s", workload.Name, suffix)
+		configMap := core.ConfigMap{}
+		configMap.APIVersion = "v1"
+		configMap.Kind = "ConfigMap"
+		configMap.Name = workload.Name + refSlug + "-bind-" + suffix
+		configMap.Labels = labels
+		configMap.Annotations = util.Annotations(workload.Labels(), "ConfigMap")
+		for key, content := range data {
+			if utf8.Valid(content) {
+				if configMap.Data == nil {
+					configMap.Data = make(map[string]string)
+				}
+				configMap.Data[key] = string(content)
+			} else {
+				if configMap.BinaryData == nil {
+					configMap.BinaryData = make(map[string][]byte)
+				}
+				configMap.BinaryData[key] = content
+			}
+		}
+		configMaps = append(configMaps, configMap)
+
+		volumes[volumeName] = core.Volume{
+			Name: volumeName,
+			VolumeSource: core.VolumeSource{
+				ConfigMap: &core.ConfigMapVolumeSource{
+					LocalObjectReference: core.LocalObjectReference{Name: configMap.Name},
+				},
+			},
+		}
+		volumeMount := core.VolumeMount{
+			Name:      volumeName,
+			MountPath: mount.Target,
+			ReadOnly:  true,
+		}
+		if !isDir {
+			// a single file is mounted on its own, not replacing the rest of the directory
+			volumeMount.SubPath = filepath.Base(mount.Source)
+		}
+		volumeMounts = append(volumeMounts, volumeMount)
+	}
+
+	return volumes, volumeMounts, configMaps
+}
+
+// readBindMountSource reads a file or all files in a directory, keyed by their file names
+func readBindMountSource(source string) (map[string][]byte, bool, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, false, err
+	}
+	files := []string{source}
+	if info.IsDir() {
+		entries, err := os.ReadDir(source)
+		if err != nil {
+			return nil, false, err
+		}
+		files = []string{}
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() {
+				return nil, false, fmt.Errorf("'%s' is not a regular file, only flat directories are supported", entry.Name())
+			}
+			files = append(files, filepath.Join(source, entry.Name()))
+		}
+	}
+
+	data := make(map[string][]byte)
+	size := 0
+	for _, file := range files {
+		key := filepath.Base(file)
+		if !configMapKeyRegexp.MatchString(key) {
+			return nil, false, fmt.Errorf("'%s' is not a valid ConfigMap key", key)
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, false, err
+		}
+		size += len(content)
+		if size > maxConfigMapSize {
+			return nil, false, fmt.Errorf("content exceeds the maximum size of a ConfigMap (1MiB)")
+		}
+		data[key] = content
+	}
+	return data, info.IsDir(), nil
+}
+
+// composeServiceConfigsToK8s converts the configs used by a Compose service into ConfigMaps and mounts them into the
+// container. Configs are mounted at `/<name>` unless a target is given.
+func composeServiceConfigsToK8s(
+	workload *ir.Service,
+	refSlug string,
+	projectConfigs map[string]*ir.FileObject,
+	labels map[string]string,
+) (map[string]core.Volume, []core.VolumeMount, []core.ConfigMap) {
+	volumes := make(map[string]core.Volume)
+	volumeMounts := []core.VolumeMount{}
+	configMaps := []core.ConfigMap{}
+
+	for _, ref := range workload.AsCompose().Configs {
+		config, ok := projectConfigs[ref.Source]
+		if !ok {
+			continue
+		}
+		target := ref.Target
+		if target == "" {
+			target = "/" + ref.Source
+		}
+		volumeName := fmt.Sprintf("%s-config-%s", workload.Name, util.Sanitize(ref.Source))
+
+		configMapName := config.ExternalName()
+		if !config.IsExternal() {
+			configMap, err := composeConfigToConfigMap(workload, refSlug, config, labels)
+			if err != nil {
+				logrus.Errorf("Service '%s': %s", workload.Name, err)
+				continue
+			}
+			configMapName = configMap.Name
+			configMaps = append(configMaps, *configMap)
+		}
+
+		volumes[volumeName] = core.Volume{
+			Name: volumeName,
+			VolumeSource: core.VolumeSource{
+				ConfigMap: &core.ConfigMapVolumeSource{
+					LocalObjectReference: core.LocalObjectReference{Name: configMapName},
+					Items:                fileReferenceToItems(composeTypes.FileReferenceConfig(ref)),
+				},
+			},
+		}
+		volumeMounts = append(volumeMounts, core.VolumeMount{
+			Name:      volumeName,
+			MountPath: target,
+			SubPath:   ref.Source,
+			ReadOnly:  true,
+		})
+	}
+
+	return volumes, volumeMounts, configMaps
+}
+
+// composeServiceSecretsToK8s converts the secrets used by a Compose service into Secrets and mounts them into the
+// container. Just like with Compose, secrets are mounted at `/run/secrets/<name>` unless a target is given, relative
+// targets are placed in `/run/secrets` too.
+func composeServiceSecretsToK8s(
+	workload *ir.Service,
+	refSlug string,
+	projectSecrets map[string]*ir.FileObject,
+	labels map[string]string,
+) (map[string]core.Volume, []core.VolumeMount, []core.Secret) {
+	volumes := make(map[string]core.Volume)
+	volumeMounts := []core.VolumeMount{}
+	secrets := []core.Secret{}
+
+	for _, ref := range workload.AsCompose().Secrets {
+		secret, ok := projectSecrets[ref.Source]
+		if !ok {
+			continue
+		}
+		target := ref.Target
+		if target == "" {
+			target = ref.Source
+		}
+		if !path.IsAbs(target) {
+			target = path.Join("/run/secrets", target)
+		}
+		volumeName := fmt.Sprintf("%s-secret-%s", workload.Name, util.Sanitize(ref.Source))
+
+		secretName := secret.ExternalName()
+		if !secret.IsExternal() {
+			k8sSecret, err := composeSecretToSecret(workload, refSlug, secret, labels)
+			if err != nil {
+				logrus.Errorf("Service '%s': %s", workload.Name, err)
+				continue
+			}
+			secretName = k8sSecret.Name
+			secrets = append(secrets, *k8sSecret)
+		}
+
+		volumes[volumeName] = core.Volume{
+			Name: volumeName,
+			VolumeSource: core.VolumeSource{
+				Secret: &core.SecretVolumeSource{
+					SecretName: secretName,
+					Items:      fileReferenceToItems(composeTypes.FileReferenceConfig(ref)),
+				},
+			},
+		}
+		volumeMounts = append(volumeMounts, core.VolumeMount{
+			Name:      volumeName,
+			MountPath: target,
+			SubPath:   ref.Source,
+			ReadOnly:  true,
+		})
+	}
+
+	return volumes, volumeMounts, secrets
+}
+
+// fileReferenceToItems selects the single key of a ConfigMap or Secret that holds the content of a Compose config or
+// secret. The key is the name of the config/secret, which is also what has to be used for external objects.
+func fileReferenceToItems(ref composeTypes.FileReferenceConfig) []core.KeyToPath {
+	item := core.KeyToPath{
+		Key:  ref.Source,
+		Path: ref.Source,
+	}
+	if ref.Mode != nil {
+		mode := int32(*ref.Mode)
+		item.Mode = &mode
+	}
+	return []core.KeyToPath{item}
+}
+
+func composeConfigToConfigMap(workload *ir.Service, refSlug string, config *ir.FileObject, labels map[string]string) (*core.ConfigMap, error) {
+	content, err := config.Content()
+	if err != nil {
+		return nil, err
+	}
+	configMap := core.ConfigMap{}
+	configMap.APIVersion = "v1"
+	configMap.Kind = "ConfigMap"
+	configMap.Name = workload.Name + refSlug + "-config-" + util.Sanitize(config.Name)
+	configMap.Labels = labels
+	configMap.Annotations = util.Annotations(workload.Labels(), "ConfigMap")
+	if utf8.Valid(content) {
+		configMap.Data = map[string]string{config.Name: string(content)}
+	} else {
+		configMap.BinaryData = map[string][]byte{config.Name: content}
+	}
+	return &configMap, nil
+}
+
+func composeSecretToSecret(workload *ir.Service, refSlug string, secret *ir.FileObject, labels map[string]string) (*core.Secret, error) {
+	content, err := secret.Content()
+	if err != nil {
+		return nil, err
+	}
+	k8sSecret := core.Secret{}
+	k8sSecret.APIVersion = "v1"
+	k8sSecret.Kind = "Secret"
+	k8sSecret.Name = workload.Name + refSlug + "-secret-" + util.Sanitize(secret.Name)
+	k8sSecret.Labels = labels
+	k8sSecret.Annotations = util.Annotations(workload.Labels(), "Secret")
+	if utf8.Valid(content) {
+		k8sSecret.StringData = map[string]string{secret.Name: string(content)}
+	} else {
+		k8sSecret.Data = map[string][]byte{secret.Name: content}
+	}
+	return &k8sSecret, nil
+}
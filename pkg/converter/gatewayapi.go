@@ -0,0 +1,152 @@
+package converter
+
+import (
+	"fmt"
+
+	"github.com/vshn/k8ify/pkg/ir"
+	"github.com/vshn/k8ify/pkg/util"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// composeServiceToRoutes is the Gateway API counterpart of composeServiceToIngress: Every port exposed via
+// `k8ify.expose` becomes an HTTPRoute, every port exposed via `k8ify.exposePlain.$port.gatewayListener` a TCPRoute or
+// UDPRoute. The Gateway terminates TLS, hence hosts in the apps domain are attached to the listener providing its
+// wildcard certificate if there is one.
+func composeServiceToRoutes(workload *ir.ParentService, refSlug string, services []core.Service, labels map[string]string, targetCfg ir.TargetCfg) []unstructured.Unstructured {
+	routes := []unstructured.Unstructured{}
+
+	var service *core.Service
+	for _, s := range services {
+		if serviceSpecIsUnexposedDefault(s.Spec) {
+			service = &s
+		}
+	}
+	workloads := []*ir.Service{}
+	if service != nil {
+		// without a default Service there are no ports to route HTTP traffic to
+		workloads = append([]*ir.Service{&workload.Service}, workload.GetParts()...)
+	}
+
+	for _, w := range workloads {
+		// invalid configurations are caught by the prechecks
+		ingressConfigs, _ := w.GetIngressConfigs()
+		for _, ingressConfig := range ingressConfigs {
+			appsDomainHosts, otherHosts := false, false
+			for _, host := range ingressConfig.Hosts {
+				if targetCfg.IsSubdomainOfAppsDomain(host) && targetCfg.GatewayAppsDomainSectionName() != "" {
+					appsDomainHosts = true
+				} else {
+					otherHosts = true
+				}
+			}
+			parentRefs := []interface{}{}
+			if appsDomainHosts {
+				parentRefs = append(parentRefs, gatewayParentRef(targetCfg, targetCfg.GatewayAppsDomainSectionName()))
+			}
+			if otherHosts {
+				parentRefs = append(parentRefs, gatewayParentRef(targetCfg, targetCfg.GatewaySectionName()))
+			}
+
+			hostnames := []interface{}{}
+			for _, host := range ingressConfig.Hosts {
+				hostnames = append(hostnames, host)
+			}
+
+			route := newUnstructured("gateway.networking.k8s.io/v1", "HTTPRoute", fmt.Sprintf("%s%s-%d", workload.Name, refSlug, ingressConfig.Port), workload, labels)
+			route.Object["spec"] = map[string]interface{}{
+				"parentRefs": parentRefs,
+				"hostnames":  hostnames,
+				"rules": []interface{}{
+					map[string]interface{}{
+						"matches": []interface{}{
+							map[string]interface{}{
+								"path": map[string]interface{}{
+									"type":  toHTTPRoutePathType(ingressConfig.PathType),
+									"value": ingressConfig.Path,
+								},
+							},
+						},
+						"backendRefs": []interface{}{
+							map[string]interface{}{
+								"name": service.Name,
+								"port": int64(ingressConfig.Port),
+							},
+						},
+					},
+				},
+			}
+			routes = append(routes, route)
+		}
+	}
+
+	for _, s := range services {
+		for _, servicePort := range s.Spec.Ports {
+			listener := util.ServiceGatewayListener(workload.Labels(), servicePort.Port)
+			if listener == "" || serviceSpecIsUnexposedDefault(s.Spec) {
+				continue
+			}
+			kind := "TCPRoute"
+			if servicePort.Protocol == core.ProtocolUDP {
+				kind = "UDPRoute"
+			}
+			route := newUnstructured("gateway.networking.k8s.io/v1alpha2", kind, workload.Name+refSlug+"-"+servicePort.Name, workload, labels)
+			route.Object["spec"] = map[string]interface{}{
+				"parentRefs": []interface{}{gatewayParentRef(targetCfg, listener)},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"backendRefs": []interface{}{
+							map[string]interface{}{
+								"name": s.Name,
+								"port": int64(servicePort.Port),
+							},
+						},
+					},
+				},
+			}
+			routes = append(routes, route)
+		}
+	}
+
+	return routes
+}
+
+// newUnstructured creates an object of a kind the K8s API packages don't know, e.g. of a CRD
+func newUnstructured(apiVersion string, kind string, name string, workload *ir.ParentService, labels map[string]string) unstructured.Unstructured {
+	route := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":   name,
+				"labels": labels,
+			},
+		},
+	}
+	if annotations := util.Annotations(workload.Labels(), kind); len(annotations) > 0 {
+		route.SetAnnotations(annotations)
+	}
+	return route
+}
+
+func gatewayParentRef(targetCfg ir.TargetCfg, sectionName string) map[string]interface{} {
+	parentRef := map[string]interface{}{
+		"name": targetCfg.GatewayName(),
+	}
+	if namespace := targetCfg.GatewayNamespace(); namespace != "" {
+		parentRef["namespace"] = namespace
+	}
+	if sectionName != "" {
+		parentRef["sectionName"] = sectionName
+	}
+	return parentRef
+}
+
+// toHTTPRoutePathType converts the path types of Ingress into their HTTPRoute equivalents. "ImplementationSpecific" has
+// no equivalent and is rejected by the prechecks.
+func toHTTPRoutePathType(pathType string) string {
+	if pathType == "Exact" {
+		return "Exact"
+	}
+	return "PathPrefix"
+}
@@ -0,0 +1,170 @@
+package converter
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	composeTypes "github.com/compose-spec/compose-go/v2/types"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Defaults used by Docker when the corresponding healthcheck field is not set
+const (
+	healthCheckDefaultInterval      = 30 * time.Second
+	healthCheckDefaultTimeout       = 30 * time.Second
+	healthCheckDefaultRetries       = 3
+	healthCheckDefaultStartInterval = 5 * time.Second
+)
+
+// hasHealthCheck returns true if the Compose service defines a healthcheck that is not disabled
+func hasHealthCheck(composeService composeTypes.ServiceConfig) bool {
+	healthCheck := composeService.HealthCheck
+	if healthCheck == nil || healthCheck.Disable || len(healthCheck.Test) == 0 {
+		return false
+	}
+	return healthCheck.Test[0] != "NONE"
+}
+
+// composeHealthCheckToProbes converts the healthcheck of a Compose service into a liveness and a startup probe. The
+// readiness probe stays disabled, just like it does by default for probes configured via labels.
+func composeHealthCheckToProbes(healthCheck *composeTypes.HealthCheckConfig) (*core.Probe, *core.Probe) {
+	interval := healthCheckDuration(healthCheck.Interval, healthCheckDefaultInterval)
+	timeout := healthCheckDuration(healthCheck.Timeout, healthCheckDefaultTimeout)
+	retries := int32(healthCheckDefaultRetries)
+	if healthCheck.Retries != nil && *healthCheck.Retries > 0 {
+		retries = int32(min(*healthCheck.Retries, math.MaxInt32))
+	}
+
+	livenessProbe := &core.Probe{
+		ProbeHandler:     healthCheckTestToProbeHandler(healthCheck.Test),
+		PeriodSeconds:    durationToSeconds(interval),
+		TimeoutSeconds:   durationToSeconds(timeout),
+		SuccessThreshold: 1,
+		FailureThreshold: retries,
+	}
+
+	// During the start period failures are not counted by Docker, we give the startup probe enough attempts to cover
+	// the whole start period. Without a start period the same defaults as for label based probes apply.
+	startupProbe := livenessProbe.DeepCopy()
+	startupProbe.PeriodSeconds = 10
+	startupProbe.FailureThreshold = 30
+	if healthCheck.StartPeriod != nil && *healthCheck.StartPeriod > 0 {
+		startInterval := healthCheckDuration(healthCheck.StartInterval, healthCheckDefaultStartInterval)
+		startupProbe.PeriodSeconds = durationToSeconds(startInterval)
+		startupProbe.FailureThreshold = max(durationToSeconds(time.Duration(*healthCheck.StartPeriod))/startupProbe.PeriodSeconds, 1) + retries
+	}
+
+	return livenessProbe, startupProbe
+}
+
+func healthCheckDuration(duration *composeTypes.Duration, defaultValue time.Duration) time.Duration {
+	if duration == nil || *duration <= 0 {
+		return defaultValue
+	}
+	return time.Duration(*duration)
+}
+
+// durationToSeconds rounds up to full seconds, K8s probes do not support shorter periods
+func durationToSeconds(duration time.Duration) int32 {
+	return int32(max(math.Ceil(duration.Seconds()), 1))
+}
+
+// healthCheckTestToProbeHandler converts the `test` of a Compose healthcheck into a probe handler. Simple HTTP checks
+// via curl/wget and TCP checks via nc against localhost are converted into HTTP GET and TCP socket probes, everything
+// else is executed as is inside the container.
+func healthCheckTestToProbeHandler(test composeTypes.HealthCheckTest) core.ProbeHandler {
+	var command []string
+	var args []string
+	switch test[0] {
+	case "CMD":
+		command = test[1:]
+		args = command
+	case "CMD-SHELL":
+		shellCommand := strings.Join(test[1:], " ")
+		command = []string{"/bin/sh", "-c", shellCommand}
+		args = strings.Fields(shellCommand)
+		// `|| exit 1` is commonly appended to map all errors to the exit code expected by Docker
+		if len(args) > 3 && strings.Join(args[len(args)-3:], " ") == "|| exit 1" {
+			args = args[:len(args)-3]
+		}
+	default:
+		command = test
+		args = command
+	}
+
+	if httpGet := argsToHTTPGetAction(args); httpGet != nil {
+		return core.ProbeHandler{HTTPGet: httpGet}
+	}
+	if tcpSocket := argsToTCPSocketAction(args); tcpSocket != nil {
+		return core.ProbeHandler{TCPSocket: tcpSocket}
+	}
+	return core.ProbeHandler{Exec: &core.ExecAction{Command: command}}
+}
+
+// argsToHTTPGetAction recognizes commands like `curl -f http://localhost:8080/health`. Any argument other than flags
+// and a single URL pointing to localhost prevents the conversion, since we can't know what it would do.
+func argsToHTTPGetAction(args []string) *core.HTTPGetAction {
+	if len(args) < 2 || (args[0] != "curl" && args[0] != "wget") {
+		return nil
+	}
+	var target *url.URL
+	for _, arg := range args[1:] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		u, err := url.Parse(arg)
+		if err != nil || target != nil || (u.Scheme != "http" && u.Scheme != "https") || !isLocalhost(u.Hostname()) {
+			return nil
+		}
+		target = u
+	}
+	if target == nil {
+		return nil
+	}
+
+	scheme := core.URISchemeHTTP
+	port := 80
+	if target.Scheme == "https" {
+		scheme = core.URISchemeHTTPS
+		port = 443
+	}
+	if target.Port() != "" {
+		p, err := strconv.Atoi(target.Port())
+		if err != nil {
+			return nil
+		}
+		port = p
+	}
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+	return &core.HTTPGetAction{
+		Path:   path,
+		Port:   intstr.FromInt32(int32(port)),
+		Scheme: scheme,
+	}
+}
+
+// argsToTCPSocketAction recognizes commands like `nc -z localhost 5432`
+func argsToTCPSocketAction(args []string) *core.TCPSocketAction {
+	if len(args) != 4 || args[0] != "nc" || args[1] != "-z" || !isLocalhost(args[2]) {
+		return nil
+	}
+	port, err := strconv.Atoi(args[3])
+	if err != nil || port < 1 || port > 65535 {
+		return nil
+	}
+	return &core.TCPSocketAction{Port: intstr.FromInt32(int32(port))}
+}
+
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
@@ -0,0 +1,55 @@
+package converter
+
+import (
+	"testing"
+	"time"
+
+	composeTypes "github.com/compose-spec/compose-go/v2/types"
+	assertions "github.com/stretchr/testify/assert"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestHealthCheckTestToProbeHandler(t *testing.T) {
+	assert := assertions.New(t)
+
+	handler := healthCheckTestToProbeHandler(composeTypes.HealthCheckTest{"CMD", "wget", "-q", "--spider", "https://127.0.0.1:8443/health?full=1"})
+	assert.Equal(&core.HTTPGetAction{Path: "/health?full=1", Port: intstr.FromInt32(8443), Scheme: core.URISchemeHTTPS}, handler.HTTPGet)
+
+	handler = healthCheckTestToProbeHandler(composeTypes.HealthCheckTest{"CMD-SHELL", "curl -f http://localhost || exit 1"})
+	assert.Equal(&core.HTTPGetAction{Path: "/", Port: intstr.FromInt32(80), Scheme: core.URISchemeHTTP}, handler.HTTPGet)
+
+	handler = healthCheckTestToProbeHandler(composeTypes.HealthCheckTest{"CMD-SHELL", "nc -z localhost 5432"})
+	assert.Equal(&core.TCPSocketAction{Port: intstr.FromInt32(5432)}, handler.TCPSocket)
+
+	// Remote hosts and additional arguments can't be expressed as HTTP GET probe
+	handler = healthCheckTestToProbeHandler(composeTypes.HealthCheckTest{"CMD", "curl", "-f", "http://example.com/"})
+	assert.Equal(&core.ExecAction{Command: []string{"curl", "-f", "http://example.com/"}}, handler.Exec)
+	handler = healthCheckTestToProbeHandler(composeTypes.HealthCheckTest{"CMD-SHELL", "curl -H 'Host: example.com' http://localhost/"})
+	assert.Equal(&core.ExecAction{Command: []string{"/bin/sh", "-c", "curl -H 'Host: example.com' http://localhost/"}}, handler.Exec)
+}
+
+func TestComposeHealthCheckToProbes(t *testing.T) {
+	assert := assertions.New(t)
+	duration := func(d time.Duration) *composeTypes.Duration {
+		cd := composeTypes.Duration(d)
+		return &cd
+	}
+	retries := uint64(4)
+
+	liveness, startup := composeHealthCheckToProbes(&composeTypes.HealthCheckConfig{
+		Test:        composeTypes.HealthCheckTest{"CMD", "true"},
+		Interval:    duration(1500 * time.Millisecond),
+		Retries:     &retries,
+		StartPeriod: duration(20 * time.Second),
+	})
+	assert.Equal(int32(2), liveness.PeriodSeconds)
+	assert.Equal(int32(30), liveness.TimeoutSeconds)
+	assert.Equal(int32(4), liveness.FailureThreshold)
+	assert.Equal(int32(5), startup.PeriodSeconds)
+	assert.Equal(int32(8), startup.FailureThreshold)
+	assert.Equal(liveness.ProbeHandler, startup.ProbeHandler)
+
+	assert.False(hasHealthCheck(composeTypes.ServiceConfig{HealthCheck: &composeTypes.HealthCheckConfig{Test: composeTypes.HealthCheckTest{"NONE"}}}))
+	assert.False(hasHealthCheck(composeTypes.ServiceConfig{HealthCheck: &composeTypes.HealthCheckConfig{Test: composeTypes.HealthCheckTest{"CMD", "true"}, Disable: true}}))
+}
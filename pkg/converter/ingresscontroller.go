@@ -0,0 +1,165 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vshn/k8ify/pkg/ir"
+	core "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// composeServiceToIngressFeatures translates the IngressFeatures of the exposed ports into the annotations of the
+// Ingress understood by the configured ingress controller. Traefik needs Middlewares and a ServersTransport instead,
+// the latter is referenced by the Service. Annotations set via `k8ify.Ingress.annotations` take precedence. All exposed
+// ports of a service share the Ingress, hence they share the features, too (see IngressPrecheck).
+func composeServiceToIngressFeatures(workload *ir.ParentService, ingress *networking.Ingress, services []core.Service, labels map[string]string, targetCfg ir.TargetCfg) ([]core.Secret, []unstructured.Unstructured) {
+	features := ir.IngressFeatures{}
+	for _, w := range append([]*ir.Service{&workload.Service}, workload.GetParts()...) {
+		// invalid configurations are caught by the prechecks
+		ingressConfigs, _ := w.GetIngressConfigs()
+		for _, ingressConfig := range ingressConfigs {
+			if !ingressConfig.Features.IsEmpty() {
+				features = ingressConfig.Features
+			}
+		}
+	}
+	if features.IsEmpty() {
+		return []core.Secret{}, []unstructured.Unstructured{}
+	}
+
+	name := ingress.Name
+	annotations := map[string]string{}
+	secrets := []core.Secret{}
+	others := []unstructured.Unstructured{}
+
+	switch targetCfg.IngressController() {
+	case ir.IngressControllerNginx:
+		prefix := "nginx.ingress.kubernetes.io/"
+		if features.MaxBodySize > 0 {
+			annotations[prefix+"proxy-body-size"] = fmt.Sprint(features.MaxBodySize)
+		}
+		if len(features.AllowFrom) > 0 {
+			annotations[prefix+"whitelist-source-range"] = strings.Join(features.AllowFrom, ",")
+		}
+		if len(features.BasicAuth) > 0 {
+			secrets = append(secrets, basicAuthSecret(name, "auth", features.BasicAuth, labels))
+			annotations[prefix+"auth-type"] = "basic"
+			annotations[prefix+"auth-secret"] = name + "-basic-auth"
+		}
+		if features.Timeout > 0 {
+			annotations[prefix+"proxy-read-timeout"] = fmt.Sprint(features.Timeout)
+			annotations[prefix+"proxy-send-timeout"] = fmt.Sprint(features.Timeout)
+		}
+		if features.RedirectToHTTPS != nil {
+			annotations[prefix+"ssl-redirect"] = fmt.Sprint(*features.RedirectToHTTPS)
+		}
+	case ir.IngressControllerHAProxy:
+		prefix := "haproxy-ingress.github.io/"
+		if features.MaxBodySize > 0 {
+			annotations[prefix+"proxy-body-size"] = fmt.Sprint(features.MaxBodySize)
+		}
+		if len(features.AllowFrom) > 0 {
+			annotations[prefix+"allowlist-source-range"] = strings.Join(features.AllowFrom, ",")
+		}
+		if len(features.BasicAuth) > 0 {
+			secrets = append(secrets, basicAuthSecret(name, "auth", features.BasicAuth, labels))
+			annotations[prefix+"auth-type"] = "basic"
+			annotations[prefix+"auth-secret"] = name + "-basic-auth"
+		}
+		if features.Timeout > 0 {
+			annotations[prefix+"timeout-server"] = fmt.Sprintf("%ds", features.Timeout)
+		}
+		if features.RedirectToHTTPS != nil {
+			annotations[prefix+"ssl-redirect"] = fmt.Sprint(*features.RedirectToHTTPS)
+		}
+	case ir.IngressControllerTraefik:
+		// Traefik references its CRDs as `$namespace-$name@kubernetescrd`
+		namespace := targetCfg.Namespace()
+		middlewares := []string{}
+		addMiddleware := func(suffix string, spec map[string]interface{}) {
+			middleware := newUnstructured("traefik.io/v1alpha1", "Middleware", name+"-"+suffix, workload, labels)
+			middleware.Object["spec"] = spec
+			others = append(others, middleware)
+			middlewares = append(middlewares, fmt.Sprintf("%s-%s-%s@kubernetescrd", namespace, name, suffix))
+		}
+		if features.RedirectToHTTPS != nil && *features.RedirectToHTTPS {
+			addMiddleware("redirect-https", map[string]interface{}{
+				"redirectScheme": map[string]interface{}{
+					"scheme":    "https",
+					"permanent": true,
+				},
+			})
+		}
+		if len(features.AllowFrom) > 0 {
+			sourceRange := []interface{}{}
+			for _, source := range features.AllowFrom {
+				sourceRange = append(sourceRange, source)
+			}
+			addMiddleware("allow-from", map[string]interface{}{
+				"ipAllowList": map[string]interface{}{
+					"sourceRange": sourceRange,
+				},
+			})
+		}
+		if len(features.BasicAuth) > 0 {
+			secrets = append(secrets, basicAuthSecret(name, "users", features.BasicAuth, labels))
+			addMiddleware("basic-auth", map[string]interface{}{
+				"basicAuth": map[string]interface{}{
+					"secret": name + "-basic-auth",
+				},
+			})
+		}
+		if features.MaxBodySize > 0 {
+			addMiddleware("max-body-size", map[string]interface{}{
+				"buffering": map[string]interface{}{
+					"maxRequestBodyBytes": features.MaxBodySize,
+				},
+			})
+		}
+		if len(middlewares) > 0 {
+			annotations["traefik.ingress.kubernetes.io/router.middlewares"] = strings.Join(middlewares, ",")
+		}
+		if features.Timeout > 0 {
+			// timeouts are a property of the connection to the Service
+			serversTransport := newUnstructured("traefik.io/v1alpha1", "ServersTransport", name, workload, labels)
+			serversTransport.Object["spec"] = map[string]interface{}{
+				"forwardingTimeouts": map[string]interface{}{
+					"responseHeaderTimeout": fmt.Sprintf("%ds", features.Timeout),
+				},
+			}
+			others = append(others, serversTransport)
+			for i := range services {
+				if !serviceSpecIsUnexposedDefault(services[i].Spec) {
+					continue
+				}
+				if services[i].Annotations == nil {
+					services[i].Annotations = map[string]string{}
+				}
+				services[i].Annotations["traefik.ingress.kubernetes.io/service.serverstransport"] = fmt.Sprintf("%s-%s@kubernetescrd", namespace, name)
+			}
+		}
+	}
+
+	if ingress.Annotations == nil {
+		ingress.Annotations = map[string]string{}
+	}
+	for key, value := range annotations {
+		if _, ok := ingress.Annotations[key]; !ok {
+			ingress.Annotations[key] = value
+		}
+	}
+	return secrets, others
+}
+
+// basicAuthSecret creates the Secret holding the users of the basic authentication in htpasswd format
+func basicAuthSecret(name string, key string, users []string, labels map[string]string) core.Secret {
+	secret := core.Secret{}
+	secret.APIVersion = "v1"
+	secret.Kind = "Secret"
+	secret.Name = name + "-basic-auth"
+	secret.Labels = labels
+	secret.StringData = map[string]string{key: strings.Join(users, "\n") + "\n"}
+	return secret
+}
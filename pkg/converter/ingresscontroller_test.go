@@ -0,0 +1,53 @@
+package converter
+
+import (
+	"testing"
+
+	composeTypes "github.com/compose-spec/compose-go/v2/types"
+	assertions "github.com/stretchr/testify/assert"
+	"github.com/vshn/k8ify/pkg/ir"
+	core "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+)
+
+func TestComposeServiceToIngressFeatures(t *testing.T) {
+	assert := assertions.New(t)
+	haproxy := ir.TargetCfg{"ingressController": "haproxy"}
+
+	// Without features the Ingress is left alone
+	service := ir.NewService("web", composeTypes.ServiceConfig{
+		Ports:  []composeTypes.ServicePortConfig{{Target: 8080, Published: "80"}},
+		Labels: composeTypes.Labels{"k8ify.expose": "example.com"},
+	})
+	ingress := networking.Ingress{}
+	ingress.Name = "web"
+	secrets, others := composeServiceToIngressFeatures(service, &ingress, []core.Service{}, nil, haproxy)
+	assert.Empty(secrets)
+	assert.Empty(others)
+	assert.Empty(ingress.Annotations)
+
+	// Annotations set via labels take precedence
+	service = ir.NewService("web", composeTypes.ServiceConfig{
+		Ports: []composeTypes.ServicePortConfig{{Target: 8080, Published: "80"}},
+		Labels: composeTypes.Labels{
+			"k8ify.expose":                 "example.com",
+			"k8ify.expose.basicAuth":       "alice:$apr1$hash",
+			"k8ify.expose.timeout":         "60",
+			"k8ify.expose.redirectToHTTPS": "false",
+		},
+	})
+	ingress = networking.Ingress{}
+	ingress.Name = "web"
+	ingress.Annotations = map[string]string{"haproxy-ingress.github.io/timeout-server": "2m"}
+	secrets, others = composeServiceToIngressFeatures(service, &ingress, []core.Service{}, nil, haproxy)
+	assert.Empty(others)
+	assert.Equal(map[string]string{
+		"haproxy-ingress.github.io/auth-type":      "basic",
+		"haproxy-ingress.github.io/auth-secret":    "web-basic-auth",
+		"haproxy-ingress.github.io/timeout-server": "2m",
+		"haproxy-ingress.github.io/ssl-redirect":   "false",
+	}, ingress.Annotations)
+	assert.Len(secrets, 1)
+	assert.Equal("web-basic-auth", secrets[0].Name)
+	assert.Equal(map[string]string{"auth": "alice:$apr1$hash\n"}, secrets[0].StringData)
+}
@@ -0,0 +1,105 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vshn/k8ify/pkg/ir"
+	"github.com/vshn/k8ify/pkg/util"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// composeServiceToOpenShiftRoutes is the OpenShift counterpart of composeServiceToIngress: Every host of a port exposed
+// via `k8ify.expose` becomes a Route, since a Route only serves a single host. Hosts in the apps domain use the wildcard
+// certificate of the router, all other hosts the certificate in the Secret the Ingress would use. Without an Ingress
+// cert-manager doesn't issue these certificates on its own, hence a Certificate is created for the hosts using the
+// Secret of the workload if an issuer is configured. Otherwise the Secret has to be provided.
+func composeServiceToOpenShiftRoutes(workload *ir.ParentService, refSlug string, services []core.Service, labels map[string]string, secretNames map[string]string, targetCfg ir.TargetCfg) ([]unstructured.Unstructured, []unstructured.Unstructured) {
+	routes := []unstructured.Unstructured{}
+	certificateHosts := []interface{}{}
+
+	var service *core.Service
+	for _, s := range services {
+		if serviceSpecIsUnexposedDefault(s.Spec) {
+			service = &s
+		}
+	}
+	if service == nil {
+		return routes, []unstructured.Unstructured{}
+	}
+
+	for _, w := range append([]*ir.Service{&workload.Service}, workload.GetParts()...) {
+		// invalid configurations are caught by the prechecks
+		ingressConfigs, _ := w.GetIngressConfigs()
+		for _, ingressConfig := range ingressConfigs {
+			targetPort := ""
+			for _, servicePort := range service.Spec.Ports {
+				if servicePort.Port == int32(ingressConfig.Port) {
+					targetPort = servicePort.Name
+				}
+			}
+
+			for _, host := range ingressConfig.Hosts {
+				secretName := tlsSecretName(host, workload.Name+refSlug, secretNames)
+				dnsName := host
+				wildcardPolicy := "None"
+				if strings.HasPrefix(host, "*.") {
+					// a Route with the wildcard policy "Subdomain" serves all hosts of the domain of its host
+					host = "wildcard" + strings.TrimPrefix(host, "*")
+					wildcardPolicy = "Subdomain"
+				}
+
+				tls := map[string]interface{}{
+					"termination":                   ingressConfig.TLSTermination,
+					"insecureEdgeTerminationPolicy": ingressConfig.InsecureEdgeTerminationPolicy,
+				}
+				if ingressConfig.TLSTermination != "passthrough" && !targetCfg.IsSubdomainOfAppsDomain(host) {
+					// special case: Without a certificate the router uses the cluster-wide apps domain wildcard certificate
+					tls["externalCertificate"] = map[string]interface{}{
+						"name": secretName,
+					}
+					// hosts shared with other services are covered by the Certificate of the Secret's owner
+					if secretName == workload.Name+refSlug && !slices.Contains(certificateHosts, interface{}(dnsName)) {
+						certificateHosts = append(certificateHosts, dnsName)
+					}
+				}
+
+				spec := map[string]interface{}{
+					"host":           host,
+					"wildcardPolicy": wildcardPolicy,
+					"to": map[string]interface{}{
+						"kind":   "Service",
+						"name":   service.Name,
+						"weight": int64(100),
+					},
+					"port": map[string]interface{}{
+						"targetPort": targetPort,
+					},
+					"tls": tls,
+				}
+				if ingressConfig.TLSTermination != "passthrough" {
+					// the router can't see the path of passthrough connections
+					spec["path"] = ingressConfig.Path
+				}
+
+				name := fmt.Sprintf("%s%s-%d-%s", workload.Name, refSlug, ingressConfig.Port, util.Sanitize(host))
+				route := newUnstructured("route.openshift.io/v1", "Route", name, workload, labels)
+				route.Object["spec"] = spec
+				routes = append(routes, route)
+			}
+		}
+	}
+
+	if len(certificateHosts) == 0 || targetCfg.CertManagerIssuer() == "" && targetCfg.CertManagerClusterIssuer() == "" {
+		return routes, []unstructured.Unstructured{}
+	}
+	certificate := newUnstructured("cert-manager.io/v1", "Certificate", workload.Name+refSlug, workload, labels)
+	certificate.Object["spec"] = map[string]interface{}{
+		"secretName": workload.Name + refSlug,
+		"dnsNames":   certificateHosts,
+		"issuerRef":  certManagerIssuerRef(targetCfg),
+	}
+	return routes, []unstructured.Unstructured{certificate}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 )
 
@@ -55,25 +56,47 @@ func getSecretByName(secrets []core.Secret, name string) (*core.Secret, error) {
 	return nil, fmt.Errorf("secret %q not found", name)
 }
 
+func getConfigMapByName(configMaps []core.ConfigMap, name string) (*core.ConfigMap, error) {
+	for i := range configMaps {
+		if configMaps[i].Name == name {
+			return &configMaps[i], nil
+		}
+	}
+	return nil, fmt.Errorf("configmap %q not found", name)
+}
+
 // We sort beforehand to not depend on the order of key-value entries
-func hashSecret(secret *core.Secret, hasher hash.Hash) {
-	sortedKeys := slices.Collect(maps.Keys(secret.StringData))
+func hashData[T string | []byte](data map[string]T, hasher hash.Hash) {
+	sortedKeys := slices.Collect(maps.Keys(data))
 	slices.Sort(sortedKeys)
 
 	for _, k := range sortedKeys {
 		hasher.Write([]byte(k))
-		hasher.Write([]byte(secret.StringData[k]))
+		hasher.Write([]byte(data[k]))
 	}
 }
 
+func hashSecret(secret *core.Secret, hasher hash.Hash) {
+	hashData(secret.StringData, hasher)
+	hashData(secret.Data, hasher)
+}
+
 func hashSecrets(secrets []*core.Secret, hasher hash.Hash) {
 	for _, secret := range secrets {
 		hashSecret(secret, hasher)
 	}
 }
 
-func patchPodTemplate(template *core.PodTemplateSpec, secrets []core.Secret, modifiedImages []string, forceRestartAnnotation map[string]string) {
+func hashConfigMaps(configMaps []*core.ConfigMap, hasher hash.Hash) {
+	for _, configMap := range configMaps {
+		hashData(configMap.Data, hasher)
+		hashData(configMap.BinaryData, hasher)
+	}
+}
+
+func patchPodTemplate(template *core.PodTemplateSpec, secrets []core.Secret, configMaps []core.ConfigMap, modifiedImages []string, forceRestartAnnotation map[string]string) {
 	matchingSecrets := []*core.Secret{}
+	matchingConfigMaps := []*core.ConfigMap{}
 	modified := false
 	for _, container := range template.Spec.Containers {
 		for _, env := range container.EnvFrom {
@@ -112,9 +135,30 @@ func patchPodTemplate(template *core.PodTemplateSpec, secrets []core.Secret, mod
 			matchingSecrets = append(matchingSecrets, secret)
 		}
 	}
+	// ConfigMaps and Secrets mounted via subPath are not updated in running containers, hence they need to trigger a
+	// restart too
+	for _, volume := range template.Spec.Volumes {
+		if volume.Secret != nil {
+			secret, err := getSecretByName(secrets, volume.Secret.SecretName)
+			if err != nil {
+				logrus.Infof("Volume %q: %s", volume.Name, err.Error())
+				continue
+			}
+			matchingSecrets = append(matchingSecrets, secret)
+		}
+		if volume.ConfigMap != nil {
+			configMap, err := getConfigMapByName(configMaps, volume.ConfigMap.Name)
+			if err != nil {
+				logrus.Infof("Volume %q: %s", volume.Name, err.Error())
+				continue
+			}
+			matchingConfigMaps = append(matchingConfigMaps, configMap)
+		}
+	}
 
 	hasher := sha256.New()
 	hashSecrets(matchingSecrets, hasher)
+	hashConfigMaps(matchingConfigMaps, hasher)
 	hashSum := hasher.Sum(nil)
 	if !bytes.Equal(hashSum, emptyHash) {
 		addAnnotations(&template.Annotations, map[string]string{
@@ -126,16 +170,32 @@ func patchPodTemplate(template *core.PodTemplateSpec, secrets []core.Secret, mod
 	}
 }
 
-func PatchDeployments(deployments []apps.Deployment, modifiedImages []string, secrets []core.Secret, forceRestartAnnotation map[string]string) {
+func PatchDeployments(deployments []apps.Deployment, modifiedImages []string, secrets []core.Secret, configMaps []core.ConfigMap, forceRestartAnnotation map[string]string) {
 	// don't use 'range', getting a pointer to an array element does not work with 'range'
 	for i := 0; i < len(deployments); i++ {
-		patchPodTemplate(&deployments[i].Spec.Template, secrets, modifiedImages, forceRestartAnnotation)
+		patchPodTemplate(&deployments[i].Spec.Template, secrets, configMaps, modifiedImages, forceRestartAnnotation)
 	}
 }
 
-func PatchStatefulSets(statefulSets []apps.StatefulSet, modifiedImages []string, secrets []core.Secret, forceRestartAnnotation map[string]string) {
+func PatchStatefulSets(statefulSets []apps.StatefulSet, modifiedImages []string, secrets []core.Secret, configMaps []core.ConfigMap, forceRestartAnnotation map[string]string) {
 	// don't use 'range', getting a pointer to an array element does not work with 'range'
 	for i := 0; i < len(statefulSets); i++ {
-		patchPodTemplate(&statefulSets[i].Spec.Template, secrets, modifiedImages, forceRestartAnnotation)
+		patchPodTemplate(&statefulSets[i].Spec.Template, secrets, configMaps, modifiedImages, forceRestartAnnotation)
+	}
+}
+
+func PatchDaemonSets(daemonSets []apps.DaemonSet, modifiedImages []string, secrets []core.Secret, configMaps []core.ConfigMap, forceRestartAnnotation map[string]string) {
+	// don't use 'range', getting a pointer to an array element does not work with 'range'
+	for i := 0; i < len(daemonSets); i++ {
+		patchPodTemplate(&daemonSets[i].Spec.Template, secrets, configMaps, modifiedImages, forceRestartAnnotation)
+	}
+}
+
+// PatchCronJobs patches the template of the Jobs created by CronJobs. Jobs themselves are not patched, since the pod
+// template of a Job can't be changed after creation anyway.
+func PatchCronJobs(cronJobs []batch.CronJob, modifiedImages []string, secrets []core.Secret, configMaps []core.ConfigMap, forceRestartAnnotation map[string]string) {
+	// don't use 'range', getting a pointer to an array element does not work with 'range'
+	for i := 0; i < len(cronJobs); i++ {
+		patchPodTemplate(&cronJobs[i].Spec.JobTemplate.Spec.Template, secrets, configMaps, modifiedImages, forceRestartAnnotation)
 	}
 }
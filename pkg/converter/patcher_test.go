@@ -68,3 +68,32 @@ func TestHashSecrets_UsingSameSecretTwiceIsNotEmptyString(t *testing.T) {
 	hash2 := sha256.New()
 	assert.NotEqual(hash1.Sum(nil), hash2.Sum(nil), "if the same secret is used twice, it should not result in a hash of an empty string")
 }
+
+func TestPatchPodTemplate_MountedConfigMapTriggersRestart(t *testing.T) {
+	assert := assertions.New(t)
+	configMap := core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{Name: "nginx-config"},
+		Data:       map[string]string{"nginx.conf": "server {}"},
+	}
+	template := func() *core.PodTemplateSpec {
+		return &core.PodTemplateSpec{
+			Spec: core.PodSpec{
+				Volumes: []core.Volume{{
+					Name: "config",
+					VolumeSource: core.VolumeSource{
+						ConfigMap: &core.ConfigMapVolumeSource{LocalObjectReference: core.LocalObjectReference{Name: "nginx-config"}},
+					},
+				}},
+			},
+		}
+	}
+
+	template1 := template()
+	patchPodTemplate(template1, nil, []core.ConfigMap{configMap}, nil, nil)
+	configMap.Data["nginx.conf"] = "server { listen 80; }"
+	template2 := template()
+	patchPodTemplate(template2, nil, []core.ConfigMap{configMap}, nil, nil)
+
+	assert.NotEmpty(template1.Annotations["k8ify.restart-trigger-config"])
+	assert.NotEqual(template1.Annotations["k8ify.restart-trigger-config"], template2.Annotations["k8ify.restart-trigger-config"])
+}
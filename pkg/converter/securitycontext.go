@@ -0,0 +1,147 @@
+package converter
+
+import (
+	"strconv"
+	"strings"
+
+	composeTypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/sirupsen/logrus"
+	"github.com/vshn/k8ify/pkg/ir"
+	"github.com/vshn/k8ify/pkg/util"
+	core "k8s.io/api/core/v1"
+)
+
+// restrictedSecurityContext returns true if the defaults of the "restricted" Pod Security Standard should be applied to
+// the service. The label of the service takes precedence over the target cluster configuration.
+func restrictedSecurityContext(workload *ir.Service, targetCfg ir.TargetCfg) bool {
+	if value := util.GetOptional(workload.Labels(), "k8ify.restrictedSecurityContext"); value != nil {
+		return util.IsTruthy(*value)
+	}
+	return targetCfg.RestrictedSecurityContext()
+}
+
+// composeServiceToSecurityContext converts `user`, `read_only`, `cap_add`, `cap_drop`, `privileged` and
+// `security_opt: [no-new-privileges]` into the SecurityContext of a container. Settings of the Compose service take
+// precedence over the restricted defaults. Returns nil if there is nothing to set.
+func composeServiceToSecurityContext(workload *ir.Service, targetCfg ir.TargetCfg) *core.SecurityContext {
+	composeService := workload.AsCompose()
+	restricted := restrictedSecurityContext(workload, targetCfg)
+	securityContext := core.SecurityContext{}
+
+	if restricted {
+		securityContext.RunAsNonRoot = util.GetPointer(true)
+		securityContext.AllowPrivilegeEscalation = util.GetPointer(false)
+		securityContext.Capabilities = &core.Capabilities{Drop: []core.Capability{"ALL"}}
+	}
+
+	if composeService.User != "" {
+		user, group, _ := strings.Cut(composeService.User, ":")
+		if uid, err := strconv.ParseInt(user, 10, 64); err == nil {
+			securityContext.RunAsUser = &uid
+			if uid == 0 {
+				logrus.Warnf("Service '%s': Running as root, the container won't be accepted by clusters enforcing non-root containers", workload.Name)
+				securityContext.RunAsNonRoot = nil
+			}
+		} else {
+			logrus.Warnf("Service '%s': Ignoring user '%s', K8s only supports numeric user IDs", workload.Name, user)
+		}
+		if group != "" {
+			if gid, err := strconv.ParseInt(group, 10, 64); err == nil {
+				securityContext.RunAsGroup = &gid
+			} else {
+				logrus.Warnf("Service '%s': Ignoring group '%s', K8s only supports numeric group IDs", workload.Name, group)
+			}
+		}
+	}
+
+	if composeService.ReadOnly {
+		securityContext.ReadOnlyRootFilesystem = util.GetPointer(true)
+	}
+
+	if hasNoNewPrivileges(composeService) {
+		securityContext.AllowPrivilegeEscalation = util.GetPointer(false)
+	}
+
+	if composeService.Privileged {
+		// privileged containers can always escalate their privileges, K8s rejects anything else
+		securityContext.Privileged = util.GetPointer(true)
+		securityContext.AllowPrivilegeEscalation = nil
+	}
+
+	if len(composeService.CapAdd) > 0 || len(composeService.CapDrop) > 0 {
+		if securityContext.Capabilities == nil {
+			securityContext.Capabilities = &core.Capabilities{}
+		}
+		securityContext.Capabilities.Add = toCapabilities(composeService.CapAdd)
+		if !restricted {
+			// with the restricted defaults ALL capabilities are dropped anyway
+			securityContext.Capabilities.Drop = toCapabilities(composeService.CapDrop)
+		}
+	}
+
+	if securityContext == (core.SecurityContext{}) {
+		return nil
+	}
+	return &securityContext
+}
+
+// initContainerSecurityContext returns the SecurityContext of the init containers k8ify adds to a pod, or nil if the
+// restricted defaults don't apply. The images of these containers run as root by default, hence they run as "nobody".
+func initContainerSecurityContext(workload *ir.Service, targetCfg ir.TargetCfg) *core.SecurityContext {
+	if !restrictedSecurityContext(workload, targetCfg) {
+		return nil
+	}
+	return &core.SecurityContext{
+		RunAsNonRoot:             util.GetPointer(true),
+		RunAsUser:                util.GetPointer(int64(65534)),
+		AllowPrivilegeEscalation: util.GetPointer(false),
+		Capabilities:             &core.Capabilities{Drop: []core.Capability{"ALL"}},
+	}
+}
+
+// composeServiceToPodSecurityContext collects the `group_add` of the service and all its parts and applies the
+// seccomp profile of the restricted defaults. Returns nil if there is nothing to set.
+func composeServiceToPodSecurityContext(workload *ir.ParentService, targetCfg ir.TargetCfg) *core.PodSecurityContext {
+	podSecurityContext := core.PodSecurityContext{}
+
+	if restrictedSecurityContext(&workload.Service, targetCfg) {
+		podSecurityContext.SeccompProfile = &core.SeccompProfile{Type: core.SeccompProfileTypeRuntimeDefault}
+	}
+
+	for _, service := range append([]*ir.Service{&workload.Service}, workload.GetParts()...) {
+		for _, group := range service.AsCompose().GroupAdd {
+			gid, err := strconv.ParseInt(group, 10, 64)
+			if err != nil {
+				logrus.Warnf("Service '%s': Ignoring group '%s', K8s only supports numeric group IDs", service.Name, group)
+				continue
+			}
+			podSecurityContext.SupplementalGroups = append(podSecurityContext.SupplementalGroups, gid)
+		}
+	}
+
+	if podSecurityContext.SeccompProfile == nil && len(podSecurityContext.SupplementalGroups) == 0 {
+		return nil
+	}
+	return &podSecurityContext
+}
+
+func hasNoNewPrivileges(composeService composeTypes.ServiceConfig) bool {
+	for _, opt := range composeService.SecurityOpt {
+		if opt == "no-new-privileges" || opt == "no-new-privileges:true" || opt == "no-new-privileges=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// toCapabilities converts Docker capabilities like "CAP_NET_ADMIN" into the names used by K8s, like "NET_ADMIN"
+func toCapabilities(capabilities []string) []core.Capability {
+	if len(capabilities) == 0 {
+		return nil
+	}
+	result := []core.Capability{}
+	for _, capability := range capabilities {
+		result = append(result, core.Capability(strings.TrimPrefix(strings.ToUpper(capability), "CAP_")))
+	}
+	return result
+}
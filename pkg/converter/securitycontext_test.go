@@ -0,0 +1,41 @@
+package converter
+
+import (
+	"testing"
+
+	composeTypes "github.com/compose-spec/compose-go/v2/types"
+	assertions "github.com/stretchr/testify/assert"
+	"github.com/vshn/k8ify/pkg/ir"
+	"github.com/vshn/k8ify/pkg/util"
+	core "k8s.io/api/core/v1"
+)
+
+func TestComposeServiceToSecurityContext(t *testing.T) {
+	assert := assertions.New(t)
+	restricted := ir.TargetCfg{"restrictedSecurityContext": true}
+
+	// Nothing to set
+	service := ir.NewService("app", composeTypes.ServiceConfig{})
+	assert.Nil(composeServiceToSecurityContext(&service.Service, ir.TargetCfg{}))
+
+	// Explicitly running as root conflicts with the restricted defaults, Compose wins
+	service = ir.NewService("app", composeTypes.ServiceConfig{User: "0"})
+	securityContext := composeServiceToSecurityContext(&service.Service, restricted)
+	assert.Nil(securityContext.RunAsNonRoot)
+	assert.Equal(util.GetPointer(int64(0)), securityContext.RunAsUser)
+
+	// Privileged containers must not disable privilege escalation
+	service = ir.NewService("app", composeTypes.ServiceConfig{Privileged: true, SecurityOpt: []string{"no-new-privileges"}})
+	securityContext = composeServiceToSecurityContext(&service.Service, restricted)
+	assert.Equal(util.GetPointer(true), securityContext.Privileged)
+	assert.Nil(securityContext.AllowPrivilegeEscalation)
+
+	// The label opts out of the restricted defaults
+	service = ir.NewService("app", composeTypes.ServiceConfig{
+		Labels:  composeTypes.Labels{"k8ify.restrictedSecurityContext": "false"},
+		CapDrop: []string{"cap_net_raw"},
+	})
+	securityContext = composeServiceToSecurityContext(&service.Service, restricted)
+	assert.Equal(&core.SecurityContext{Capabilities: &core.Capabilities{Drop: []core.Capability{"NET_RAW"}}}, securityContext)
+	assert.Nil(composeServiceToPodSecurityContext(service, restricted))
+}
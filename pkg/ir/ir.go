@@ -2,10 +2,12 @@ package ir
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
 
+	"github.com/docker/go-units"
 	"github.com/sirupsen/logrus"
 
 	composeTypes "github.com/compose-spec/compose-go/v2/types"
@@ -16,6 +18,8 @@ import (
 type Inputs struct {
 	Services  map[string]*ParentService
 	Volumes   map[string]*Volume
+	Configs   map[string]*FileObject
+	Secrets   map[string]*FileObject
 	TargetCfg TargetCfg
 }
 
@@ -23,6 +27,8 @@ func NewInputs() *Inputs {
 	return &Inputs{
 		Services: make(map[string]*ParentService),
 		Volumes:  make(map[string]*Volume),
+		Configs:  make(map[string]*FileObject),
+		Secrets:  make(map[string]*FileObject),
 	}
 }
 
@@ -80,6 +86,13 @@ func FromCompose(project *composeTypes.Project) *Inputs {
 		inputs.Volumes[name] = NewVolume(name, composeVolume)
 	}
 
+	for name, composeConfig := range project.Configs {
+		inputs.Configs[name] = NewFileObject(name, composeTypes.FileObjectConfig(composeConfig), project.Environment)
+	}
+	for name, composeSecret := range project.Secrets {
+		inputs.Secrets[name] = NewFileObject(name, composeTypes.FileObjectConfig(composeSecret), project.Environment)
+	}
+
 	if targetCfg, ok := project.Extensions["x-targetCfg"]; ok {
 		if targetCfgMap, ok := targetCfg.(map[string]interface{}); ok {
 			inputs.TargetCfg = targetCfgMap
@@ -137,11 +150,16 @@ func (s *Service) VolumeNames() []string {
 	return names
 }
 
+// Volumes splits the volumes mounted by this service into ReadWriteOnce and ReadWriteMany volumes k8ify has to create.
+// External volumes already exist and are therefore left out.
 func (s *Service) Volumes(volumes map[string]*Volume) (map[string]*Volume, map[string]*Volume) {
 	rwoVolumes := make(map[string]*Volume)
 	rwxVolumes := make(map[string]*Volume)
 	for _, volumeName := range s.VolumeNames() {
 		volume := volumes[volumeName]
+		if volume.IsExternal() {
+			continue
+		}
 		if volume.IsShared() {
 			rwxVolumes[volume.Name] = volume
 		} else {
@@ -154,6 +172,12 @@ func (s *Service) Volumes(volumes map[string]*Volume) (map[string]*Volume, map[s
 func (s *Service) IsSingleton() bool {
 	return util.IsSingleton(s.raw.Labels)
 }
+
+// IsGlobal returns true if the Compose service is supposed to run on every node (`deploy.mode: global`)
+func (s *Service) IsGlobal() bool {
+	return s.raw.Deploy != nil && s.raw.Deploy.Mode == "global"
+}
+
 func (s *Service) Labels() map[string]string {
 	return s.raw.Labels
 }
@@ -161,26 +185,289 @@ func (s *Service) Labels() map[string]string {
 type PublishedPort struct {
 	ServicePort   uint16
 	ContainerPort uint16
+	// Protocol is the upper case name used by K8s, i.e. "TCP", "UDP" or "SCTP"
+	Protocol string
+	// HostMode is true if the port is published on the node the container runs on (`mode: host`)
+	HostMode bool
+	HostIP   string
+}
+
+// IsTCP returns true if the port uses TCP, which is what HTTP, probes and the like need
+func (p PublishedPort) IsTCP() bool {
+	return p.Protocol == "TCP"
 }
 
+// GetPorts returns the published ports of the service. Matching ranges (e.g. "30000-30010:30000-30010") are already
+// expanded into individual ports by Compose. A range of published ports with a single target port (e.g.
+// "30000-30010:21") means Compose binds one of the published ports, hence only the start of the range is used.
 func (s *Service) GetPorts() []PublishedPort {
 	var publishedPorts []PublishedPort
 	for _, port := range s.raw.Ports {
-		publishedPort := PublishedPort{
-			ServicePort:   uint16(port.Target), // fall-back
+		protocol := strings.ToUpper(port.Protocol)
+		if protocol == "" {
+			protocol = "TCP"
+		}
+		start, _, err := publishedPortRange(port)
+		if err != nil {
+			// fall-back
+			start = uint64(port.Target)
+		}
+		publishedPorts = append(publishedPorts, PublishedPort{
+			ServicePort:   uint16(start),
 			ContainerPort: uint16(port.Target),
+			Protocol:      protocol,
+			HostMode:      port.Mode == "host",
+			HostIP:        port.HostIP,
+		})
+	}
+	return publishedPorts
+}
+
+// GetExposedPorts returns the ports listed in `expose`, which are only reachable from within the cluster. Ports which
+// are published via `ports` too are left out.
+func (s *Service) GetExposedPorts() []PublishedPort {
+	publishedPorts := s.GetPorts()
+	isPublished := func(port uint64, protocol string) bool {
+		for _, publishedPort := range publishedPorts {
+			if publishedPort.Protocol == protocol && (uint64(publishedPort.ContainerPort) == port || uint64(publishedPort.ServicePort) == port) {
+				return true
+			}
 		}
-		// port.Published can contain a range. Since we can't use this range for k8s we always use the start of the range instead.
-		portRange := strings.Split(port.Published, "-")
-		if len(portRange) > 0 {
-			p, err := strconv.ParseUint(portRange[0], 10, 16)
-			if err == nil {
-				publishedPort.ServicePort = uint16(p)
+		return false
+	}
+
+	var exposedPorts []PublishedPort
+	for _, expose := range s.raw.Expose {
+		start, end, protocol, err := exposedPortRange(expose)
+		if err != nil {
+			continue
+		}
+		for port := start; port <= end; port++ {
+			if isPublished(port, protocol) {
+				continue
 			}
+			exposedPorts = append(exposedPorts, PublishedPort{
+				ServicePort:   uint16(port),
+				ContainerPort: uint16(port),
+				Protocol:      protocol,
+			})
 		}
-		publishedPorts = append(publishedPorts, publishedPort)
 	}
-	return publishedPorts
+	return exposedPorts
+}
+
+// CheckPortRanges returns an error if a range of published or exposed ports is invalid or if the service provides more
+// than maxPorts ports, counting every port of a range
+func (s *Service) CheckPortRanges(maxPorts int) error {
+	for _, port := range s.raw.Ports {
+		if _, _, err := publishedPortRange(port); err != nil {
+			return err
+		}
+	}
+	for _, expose := range s.raw.Expose {
+		if _, _, _, err := exposedPortRange(expose); err != nil {
+			return err
+		}
+	}
+	// ranges contain at most 65536 ports, expanding them is cheap enough
+	if count := len(s.GetPorts()) + len(s.GetExposedPorts()); count > maxPorts {
+		return fmt.Errorf("%d ports are published or exposed (with all port ranges expanded), the maximum is %d. Use smaller port ranges or adjust 'x-targetCfg.maxPorts'", count, maxPorts)
+	}
+	return nil
+}
+
+// exposedPortRange parses an entry of `expose` like "3000", "53/udp" or "8000-8010/tcp"
+func exposedPortRange(expose string) (uint64, uint64, string, error) {
+	portRange, protocol, _ := strings.Cut(expose, "/")
+	protocol = strings.ToUpper(protocol)
+	if protocol == "" {
+		protocol = "TCP"
+	}
+	startString, endString, isRange := strings.Cut(portRange, "-")
+	start, err := strconv.ParseUint(startString, 10, 16)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("invalid exposed port '%s'", expose)
+	}
+	end := start
+	if isRange {
+		end, err = strconv.ParseUint(endString, 10, 16)
+		if err != nil || end < start {
+			return 0, 0, "", fmt.Errorf("invalid exposed port range '%s'", expose)
+		}
+	}
+	return start, end, protocol, nil
+}
+
+// IngressConfig describes a port exposed via Ingress
+type IngressConfig struct {
+	Port  uint16
+	Hosts []string
+	Path  string
+	// PathType is either "Prefix", "Exact" or "ImplementationSpecific"
+	PathType string
+	// TLSTermination is either "edge", "reencrypt" or "passthrough", only used by OpenShift Routes
+	TLSTermination string
+	// InsecureEdgeTerminationPolicy is either "Redirect", "Allow" or "None", only used by OpenShift Routes
+	InsecureEdgeTerminationPolicy string
+	Features                      IngressFeatures
+}
+
+// IngressFeatures are the HTTP features of an exposed port which are implemented by the ingress controller, see
+// `x-targetCfg.ingressController`
+type IngressFeatures struct {
+	// MaxBodySize is the maximum size of request bodies in bytes, 0 for the default of the ingress controller
+	MaxBodySize int64
+	// AllowFrom contains the IP addresses and CIDRs allowed to access the port, empty to allow everyone
+	AllowFrom []string
+	// BasicAuth contains the users in htpasswd format (`$user:$hash`), empty to not require authentication
+	BasicAuth []string
+	// Timeout is the number of seconds to wait for a response of the service, 0 for the default of the ingress controller
+	Timeout int
+	// RedirectToHTTPS is nil for the default of the ingress controller
+	RedirectToHTTPS *bool
+}
+
+func (f IngressFeatures) IsEmpty() bool {
+	return f.MaxBodySize == 0 && len(f.AllowFrom) == 0 && len(f.BasicAuth) == 0 && f.Timeout == 0 && f.RedirectToHTTPS == nil
+}
+
+// GetIngressConfigs returns the TCP ports exposed via Ingress. They are configured via `k8ify.expose.$port`, for the first
+// TCP port `k8ify.expose` works as well. The value is a comma-separated list of hosts, `.path` and `.pathType` restrict
+// the Ingress to the given path (default "/" with path type "Prefix"). `.tlsTermination` and
+// `.insecureEdgeTerminationPolicy` configure OpenShift Routes (default "edge" and "Redirect"). `.maxBodySize`,
+// `.allowFrom`, `.basicAuth`, `.timeout` and `.redirectToHTTPS` configure the IngressFeatures.
+func (s *Service) GetIngressConfigs() ([]IngressConfig, error) {
+	ingressConfigs := []IngressConfig{}
+	first := true
+	for _, port := range s.GetPorts() {
+		if !port.IsTCP() {
+			// HTTP(S) requires TCP
+			continue
+		}
+		// we expect the config to be in "k8ify.expose.PORT"
+		configPrefix := fmt.Sprintf("k8ify.expose.%d", port.ServicePort)
+		config := util.SubConfig(s.Labels(), configPrefix, "host")
+		if _, ok := config["host"]; !ok && first {
+			// for the first port we also accept config in "k8ify.expose"
+			configPrefix = "k8ify.expose"
+			config = util.SubConfig(s.Labels(), configPrefix, "host")
+		}
+		first = false
+
+		host, ok := config["host"]
+		if !ok {
+			continue
+		}
+		ingressConfig := IngressConfig{
+			Port:                          port.ServicePort,
+			Path:                          "/",
+			PathType:                      "Prefix",
+			TLSTermination:                "edge",
+			InsecureEdgeTerminationPolicy: "Redirect",
+		}
+		for _, h := range strings.Split(host, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				ingressConfig.Hosts = append(ingressConfig.Hosts, h)
+			}
+		}
+		if path, ok := config["path"]; ok {
+			if !strings.HasPrefix(path, "/") {
+				return nil, fmt.Errorf("%s.path must start with '/', got %q", configPrefix, path)
+			}
+			ingressConfig.Path = path
+		}
+		if pathType, ok := config["pathType"]; ok {
+			if pathType != "Prefix" && pathType != "Exact" && pathType != "ImplementationSpecific" {
+				return nil, fmt.Errorf("%s.pathType must be Prefix, Exact or ImplementationSpecific, got %q", configPrefix, pathType)
+			}
+			ingressConfig.PathType = pathType
+		}
+		if tlsTermination, ok := config["tlsTermination"]; ok {
+			if tlsTermination != "edge" && tlsTermination != "reencrypt" && tlsTermination != "passthrough" {
+				return nil, fmt.Errorf("%s.tlsTermination must be edge, reencrypt or passthrough, got %q", configPrefix, tlsTermination)
+			}
+			ingressConfig.TLSTermination = tlsTermination
+		}
+		if policy, ok := config["insecureEdgeTerminationPolicy"]; ok {
+			if policy != "Redirect" && policy != "Allow" && policy != "None" {
+				return nil, fmt.Errorf("%s.insecureEdgeTerminationPolicy must be Redirect, Allow or None, got %q", configPrefix, policy)
+			}
+			ingressConfig.InsecureEdgeTerminationPolicy = policy
+		}
+		features, err := ingressFeatures(config, configPrefix)
+		if err != nil {
+			return nil, err
+		}
+		ingressConfig.Features = features
+		if len(ingressConfig.Hosts) > 0 {
+			ingressConfigs = append(ingressConfigs, ingressConfig)
+		}
+	}
+	return ingressConfigs, nil
+}
+
+func ingressFeatures(config map[string]string, configPrefix string) (IngressFeatures, error) {
+	features := IngressFeatures{}
+	if maxBodySize, ok := config["maxBodySize"]; ok {
+		size, err := units.RAMInBytes(maxBodySize)
+		if err != nil || size <= 0 {
+			return features, fmt.Errorf("%s.maxBodySize must be a positive size like '10m', got %q", configPrefix, maxBodySize)
+		}
+		features.MaxBodySize = size
+	}
+	if allowFrom, ok := config["allowFrom"]; ok {
+		for _, source := range strings.Split(allowFrom, ",") {
+			if source = strings.TrimSpace(source); source == "" {
+				continue
+			}
+			if _, _, err := net.ParseCIDR(source); err != nil && net.ParseIP(source) == nil {
+				return features, fmt.Errorf("%s.allowFrom must be a comma-separated list of IP addresses and CIDRs, got %q", configPrefix, source)
+			}
+			features.AllowFrom = append(features.AllowFrom, source)
+		}
+	}
+	if basicAuth, ok := config["basicAuth"]; ok {
+		for _, user := range strings.Split(basicAuth, ",") {
+			if user = strings.TrimSpace(user); user == "" {
+				continue
+			}
+			if name, hash, _ := strings.Cut(user, ":"); name == "" || hash == "" {
+				return features, fmt.Errorf("%s.basicAuth must be a comma-separated list of users in htpasswd format ('$user:$hash'), got %q", configPrefix, user)
+			}
+			features.BasicAuth = append(features.BasicAuth, user)
+		}
+	}
+	if timeout, ok := config["timeout"]; ok {
+		seconds, err := strconv.Atoi(timeout)
+		if err != nil || seconds <= 0 {
+			return features, fmt.Errorf("%s.timeout must be a positive number of seconds, got %q", configPrefix, timeout)
+		}
+		features.Timeout = seconds
+	}
+	if redirectToHTTPS, ok := config["redirectToHTTPS"]; ok {
+		features.RedirectToHTTPS = util.GetPointer(util.IsTruthy(redirectToHTTPS))
+	}
+	return features, nil
+}
+
+// publishedPortRange returns the first and last published port. Without published port the target port is used.
+func publishedPortRange(port composeTypes.ServicePortConfig) (uint64, uint64, error) {
+	if port.Published == "" {
+		return uint64(port.Target), uint64(port.Target), nil
+	}
+	startString, endString, isRange := strings.Cut(port.Published, "-")
+	start, err := strconv.ParseUint(startString, 10, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid published port '%s'", port.Published)
+	}
+	end := start
+	if isRange {
+		end, err = strconv.ParseUint(endString, 10, 16)
+		if err != nil || end < start {
+			return 0, 0, fmt.Errorf("invalid published port range '%s'", port.Published)
+		}
+	}
+	return start, end, nil
 }
 
 // Volume provides some k8ify-specific abstractions & utility around Compose
@@ -204,6 +491,20 @@ func (v *Volume) IsShared() bool {
 func (v *Volume) IsSingleton() bool {
 	return util.IsSingleton(v.raw.Labels)
 }
+
+// IsExternal returns true if the volume is marked `external: true`, i.e. it refers to an existing PersistentVolumeClaim
+func (v *Volume) IsExternal() bool {
+	return bool(v.raw.External)
+}
+
+// ExternalName returns the name of the existing PersistentVolumeClaim an external volume refers to
+func (v *Volume) ExternalName() string {
+	if v.raw.Name != "" {
+		return v.raw.Name
+	}
+	return v.Name
+}
+
 func (v *Volume) Labels() map[string]string {
 	return v.raw.Labels
 }
@@ -215,6 +516,62 @@ func (v *Volume) SizeIsMissing() bool {
 	return util.StorageSizeRaw(v.raw.Labels) == nil
 }
 
+// FileObject provides some k8ify-specific abstractions & utility around Compose
+// config and secret configurations, which are both files mounted into containers.
+type FileObject struct {
+	Name string
+
+	raw         composeTypes.FileObjectConfig
+	environment map[string]string
+}
+
+func NewFileObject(name string, composeFileObject composeTypes.FileObjectConfig, environment map[string]string) *FileObject {
+	return &FileObject{
+		Name:        name,
+		raw:         composeFileObject,
+		environment: environment,
+	}
+}
+
+// IsExternal returns true if the object is not managed by k8ify but has to exist in the cluster already
+func (f *FileObject) IsExternal() bool {
+	return bool(f.raw.External)
+}
+
+// ExternalName returns the name of the existing ConfigMap or Secret to be used for external objects
+func (f *FileObject) ExternalName() string {
+	if f.raw.Name != "" {
+		return f.raw.Name
+	}
+	return f.Name
+}
+
+func (f *FileObject) Labels() map[string]string {
+	return f.raw.Labels
+}
+
+// Content returns the content of the object, which is read either from a file, an environment variable or taken
+// directly from the Compose file.
+func (f *FileObject) Content() ([]byte, error) {
+	switch {
+	case f.raw.File != "":
+		content, err := os.ReadFile(f.raw.File)
+		if err != nil {
+			return nil, fmt.Errorf("reading file of '%s': %w", f.Name, err)
+		}
+		return content, nil
+	case f.raw.Environment != "":
+		content, ok := f.environment[f.raw.Environment]
+		if !ok {
+			return nil, fmt.Errorf("environment variable '%s' of '%s' is not set", f.raw.Environment, f.Name)
+		}
+		return []byte(content), nil
+	case f.raw.Content != "":
+		return []byte(f.raw.Content), nil
+	}
+	return nil, fmt.Errorf("'%s' has neither file, environment nor content", f.Name)
+}
+
 type TargetCfg map[string]interface{}
 
 func (t TargetCfg) appsDomain() *string {
@@ -256,6 +613,472 @@ func (t TargetCfg) MaxExposeLength() int {
 	return 63
 }
 
+// BindAsConfigMap returns true if bind mounts of all services should be converted into ConfigMaps
+func (t TargetCfg) BindAsConfigMap() bool {
+	if value, ok := t["bindAsConfigMap"]; ok {
+		if enabled, ok := value.(bool); ok {
+			return enabled
+		}
+	}
+	return false
+}
+
+// WaitForDependenciesImage returns the image of the init containers waiting for the dependencies of a service
+func (t TargetCfg) WaitForDependenciesImage() string {
+	if value, ok := t["waitForDependenciesImage"]; ok {
+		if image, ok := value.(string); ok && image != "" {
+			return image
+		}
+	}
+	return "docker.io/library/busybox:1.37"
+}
+
+// WaitForDependenciesTimeout returns the number of seconds to wait for a dependency of a service. 0 means no timeout.
+func (t TargetCfg) WaitForDependenciesTimeout() int {
+	if value, ok := t["waitForDependenciesTimeout"]; ok {
+		if timeout, ok := value.(int); ok && timeout >= 0 {
+			return timeout
+		}
+	}
+	return 300
+}
+
+// MaxPorts returns the maximum number of ports a service may publish, with all port ranges expanded
+func (t TargetCfg) MaxPorts() int {
+	if value, ok := t["maxPorts"]; ok {
+		if size, ok := value.(int); ok {
+			return size
+		}
+	}
+	return 100
+}
+
+// RestrictedSecurityContext returns true if the defaults of the "restricted" Pod Security Standard should be applied to
+// all services
+func (t TargetCfg) RestrictedSecurityContext() bool {
+	if value, ok := t["restrictedSecurityContext"]; ok {
+		if enabled, ok := value.(bool); ok {
+			return enabled
+		}
+	}
+	return false
+}
+
+// NetworkPolicies returns true if NetworkPolicies restricting the traffic between the services should be generated
+func (t TargetCfg) NetworkPolicies() bool {
+	if value, ok := t["networkPolicies"]; ok {
+		if enabled, ok := value.(bool); ok {
+			return enabled
+		}
+	}
+	return false
+}
+
+// NetworkPolicyIngressNamespace returns the namespace of the ingress controller, or "" if traffic to exposed ports
+// should be allowed from all namespaces
+func (t TargetCfg) NetworkPolicyIngressNamespace() string {
+	if value, ok := t["networkPolicyIngressNamespace"]; ok {
+		if namespace, ok := value.(string); ok {
+			return namespace
+		}
+	}
+	return ""
+}
+
+// NetworkPolicyMonitoringNamespace returns the namespace of Prometheus, or "" if traffic to ports scraped via
+// ServiceMonitor should be allowed from all namespaces
+func (t TargetCfg) NetworkPolicyMonitoringNamespace() string {
+	if value, ok := t["networkPolicyMonitoringNamespace"]; ok {
+		if namespace, ok := value.(string); ok {
+			return namespace
+		}
+	}
+	return ""
+}
+
+// subCfg returns the nested target configuration stored under `key`, or an empty one if there is none
+func (t TargetCfg) subCfg(key string) TargetCfg {
+	if value, ok := t[key]; ok {
+		if subCfg, ok := value.(map[string]interface{}); ok {
+			return subCfg
+		}
+	}
+	return TargetCfg{}
+}
+
+// KustomizeNamespace returns the namespace to be set in the generated kustomization.yaml, or "" if none is configured
+func (t TargetCfg) KustomizeNamespace() string {
+	if value, ok := t.subCfg("kustomize")["namespace"]; ok {
+		if namespace, ok := value.(string); ok {
+			return namespace
+		}
+	}
+	return ""
+}
+
+const (
+	// ExposeSchemeIngress exposes services via Ingress (the default)
+	ExposeSchemeIngress = "ingress"
+	// ExposeSchemeGatewayAPI exposes services via the routes of the Gateway API
+	ExposeSchemeGatewayAPI = "gateway-api"
+	// ExposeSchemeOpenShiftRoute exposes services via OpenShift Routes
+	ExposeSchemeOpenShiftRoute = "openshift-route"
+)
+
+const (
+	IngressControllerNginx   = "nginx"
+	IngressControllerTraefik = "traefik"
+	IngressControllerHAProxy = "haproxy"
+)
+
+// IngressController returns the ingress controller implementing the IngressFeatures, or "" if none is configured
+func (t TargetCfg) IngressController() string {
+	if value, ok := t["ingressController"]; ok {
+		if controller, ok := value.(string); ok {
+			return controller
+		}
+	}
+	return ""
+}
+
+// Namespace returns the namespace the manifests are deployed to, or "" if it is unknown. Falls back to the namespace of
+// the kustomization.yaml.
+func (t TargetCfg) Namespace() string {
+	if value, ok := t["namespace"]; ok {
+		if namespace, ok := value.(string); ok {
+			return namespace
+		}
+	}
+	return t.KustomizeNamespace()
+}
+
+// ExposeScheme returns how services labeled with `k8ify.expose` are exposed
+func (t TargetCfg) ExposeScheme() string {
+	if value, ok := t["exposeScheme"]; ok {
+		if scheme, ok := value.(string); ok {
+			return scheme
+		}
+	}
+	return ExposeSchemeIngress
+}
+
+// GatewayName returns the name of the Gateway the routes are attached to
+func (t TargetCfg) GatewayName() string {
+	if value, ok := t.subCfg("gateway")["name"]; ok {
+		if name, ok := value.(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// GatewayNamespace returns the namespace of the Gateway the routes are attached to, or "" if it's in the same namespace
+func (t TargetCfg) GatewayNamespace() string {
+	if value, ok := t.subCfg("gateway")["namespace"]; ok {
+		if namespace, ok := value.(string); ok {
+			return namespace
+		}
+	}
+	return ""
+}
+
+// GatewaySectionName returns the name of the Gateway listener HTTPRoutes are attached to, or "" for all listeners
+func (t TargetCfg) GatewaySectionName() string {
+	if value, ok := t.subCfg("gateway")["sectionName"]; ok {
+		if sectionName, ok := value.(string); ok {
+			return sectionName
+		}
+	}
+	return ""
+}
+
+// GatewayAppsDomainSectionName returns the name of the Gateway listener providing the wildcard certificate of the
+// apps domain, or "" if HTTPRoutes for hosts in the apps domain are attached like all others
+func (t TargetCfg) GatewayAppsDomainSectionName() string {
+	if value, ok := t.subCfg("gateway")["appsDomainSectionName"]; ok {
+		if sectionName, ok := value.(string); ok {
+			return sectionName
+		}
+	}
+	return ""
+}
+
+// IngressClassName returns the IngressClass of the generated Ingresses, or "" for the default IngressClass of the cluster
+func (t TargetCfg) IngressClassName() string {
+	if value, ok := t["ingressClassName"]; ok {
+		if name, ok := value.(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// CertManagerIssuer returns the name of the cert-manager Issuer issuing the certificates, or "" if none is configured
+func (t TargetCfg) CertManagerIssuer() string {
+	if value, ok := t.subCfg("certManager")["issuer"]; ok {
+		if name, ok := value.(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// CertManagerClusterIssuer returns the name of the cert-manager ClusterIssuer issuing the certificates, or "" if none is
+// configured
+func (t TargetCfg) CertManagerClusterIssuer() string {
+	if value, ok := t.subCfg("certManager")["clusterIssuer"]; ok {
+		if name, ok := value.(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// BackupEndpoint returns the S3 endpoint backups are stored at, or "" if none is configured
+func (t TargetCfg) BackupEndpoint() string {
+	if value, ok := t.subCfg("backup")["endpoint"]; ok {
+		if endpoint, ok := value.(string); ok {
+			return endpoint
+		}
+	}
+	return ""
+}
+
+// BackupBucket returns the S3 bucket backups are stored in, or "" if none is configured
+func (t TargetCfg) BackupBucket() string {
+	if value, ok := t.subCfg("backup")["bucket"]; ok {
+		if bucket, ok := value.(string); ok {
+			return bucket
+		}
+	}
+	return ""
+}
+
+// BackupCredentialsSecret returns the name of the Secret holding the S3 credentials in the keys `username` and
+// `password`. The Secret is not generated by k8ify.
+func (t TargetCfg) BackupCredentialsSecret() string {
+	if value, ok := t.subCfg("backup")["credentialsSecret"]; ok {
+		if name, ok := value.(string); ok {
+			return name
+		}
+	}
+	return "backup-credentials"
+}
+
+// BackupRepositoryPassword returns the password the backup repository is encrypted with, or "" if none is configured
+func (t TargetCfg) BackupRepositoryPassword() string {
+	if value, ok := t.subCfg("backup")["repositoryPassword"]; ok {
+		if password, ok := value.(string); ok {
+			return password
+		}
+	}
+	return ""
+}
+
+// KustomizeCommonLabels returns the labels to be set as `commonLabels` in the generated kustomization.yaml
+func (t TargetCfg) KustomizeCommonLabels() map[string]string {
+	commonLabels := make(map[string]string)
+	if value, ok := t.subCfg("kustomize")["commonLabels"]; ok {
+		if labels, ok := value.(map[string]interface{}); ok {
+			for k, v := range labels {
+				commonLabels[k] = fmt.Sprint(v)
+			}
+		}
+	}
+	return commonLabels
+}
+
+// AutoscaleConfig holds the settings for a HorizontalPodAutoscaler, parsed from the `k8ify.autoscale.*` labels.
+// Cpu and Memory are target average utilizations in percent of the requested resources.
+type AutoscaleConfig struct {
+	Min    int32
+	Max    int32
+	Cpu    *int32
+	Memory *int32
+}
+
+// AutoscaleConfigPointer Parses the config values for autoscaling. Returns nil if autoscaling is not configured.
+func AutoscaleConfigPointer(labels map[string]string) (*AutoscaleConfig, error) {
+	config := util.SubConfig(labels, "k8ify.autoscale", "")
+	delete(config, "")
+	if len(config) == 0 {
+		return nil, nil
+	}
+	maxReplicas, err := parseAutoscaleValue(config, "max")
+	if err != nil {
+		return nil, err
+	}
+	if maxReplicas == nil {
+		return nil, fmt.Errorf("k8ify.autoscale.max is required when autoscaling is configured")
+	}
+	minReplicas, err := parseAutoscaleValue(config, "min")
+	if err != nil {
+		return nil, err
+	}
+	if minReplicas == nil {
+		minReplicas = util.GetPointer(int32(1))
+	}
+	if *minReplicas < 1 || *maxReplicas < *minReplicas {
+		return nil, fmt.Errorf("k8ify.autoscale.min (%d) must be at least 1 and must not exceed k8ify.autoscale.max (%d)", *minReplicas, *maxReplicas)
+	}
+	cpu, err := parseAutoscaleValue(config, "cpu")
+	if err != nil {
+		return nil, err
+	}
+	memory, err := parseAutoscaleValue(config, "memory")
+	if err != nil {
+		return nil, err
+	}
+	if cpu == nil && memory == nil {
+		// same default Kubernetes applies to HorizontalPodAutoscalers without metrics
+		cpu = util.GetPointer(int32(80))
+	}
+	return &AutoscaleConfig{
+		Min:    *minReplicas,
+		Max:    *maxReplicas,
+		Cpu:    cpu,
+		Memory: memory,
+	}, nil
+}
+
+func parseAutoscaleValue(config map[string]string, key string) (*int32, error) {
+	value := util.FilterBlank(util.GetOptional(config, key))
+	if value == nil {
+		return nil, nil
+	}
+	number, err := strconv.ParseInt(strings.TrimSuffix(*value, "%"), 10, 32)
+	if err != nil || number < 1 {
+		return nil, fmt.Errorf("k8ify.autoscale.%s must be a positive number, got %q", key, *value)
+	}
+	return util.GetPointer(int32(number)), nil
+}
+
+// JobConfig holds the settings for services that are run to completion instead of continuously, parsed from the
+// `k8ify.kind` label and its companions.
+type JobConfig struct {
+	// Kind is either "Job" or "CronJob"
+	Kind                    string
+	Schedule                *string
+	BackoffLimit            *int32
+	TTLSecondsAfterFinished *int32
+	ConcurrencyPolicy       *string
+}
+
+// JobConfigPointer Parses the config values for Jobs and CronJobs. Returns nil if the service is not a Job or CronJob.
+func JobConfigPointer(labels map[string]string) (*JobConfig, error) {
+	kind := util.FilterBlank(util.GetOptional(labels, "k8ify.kind"))
+	schedule := util.FilterBlank(util.GetOptional(labels, "k8ify.schedule"))
+	if kind == nil {
+		if schedule != nil {
+			return nil, fmt.Errorf("k8ify.schedule requires k8ify.kind to be CronJob")
+		}
+		return nil, nil
+	}
+	if *kind != "Job" && *kind != "CronJob" {
+		return nil, fmt.Errorf("k8ify.kind must be Job or CronJob, got %q", *kind)
+	}
+	if *kind == "CronJob" && schedule == nil {
+		return nil, fmt.Errorf("k8ify.schedule is required for a CronJob")
+	}
+	if *kind == "Job" && schedule != nil {
+		return nil, fmt.Errorf("k8ify.schedule can only be used with a CronJob, use k8ify.kind: CronJob instead")
+	}
+	backoffLimit, err := parseJobValue(labels, "backoffLimit")
+	if err != nil {
+		return nil, err
+	}
+	ttlSecondsAfterFinished, err := parseJobValue(labels, "ttlSecondsAfterFinished")
+	if err != nil {
+		return nil, err
+	}
+	concurrencyPolicy := util.FilterBlank(util.GetOptional(labels, "k8ify.concurrencyPolicy"))
+	if concurrencyPolicy != nil {
+		if *kind != "CronJob" {
+			return nil, fmt.Errorf("k8ify.concurrencyPolicy can only be used with a CronJob")
+		}
+		if *concurrencyPolicy != "Allow" && *concurrencyPolicy != "Forbid" && *concurrencyPolicy != "Replace" {
+			return nil, fmt.Errorf("k8ify.concurrencyPolicy must be Allow, Forbid or Replace, got %q", *concurrencyPolicy)
+		}
+	}
+	return &JobConfig{
+		Kind:                    *kind,
+		Schedule:                schedule,
+		BackoffLimit:            backoffLimit,
+		TTLSecondsAfterFinished: ttlSecondsAfterFinished,
+		ConcurrencyPolicy:       concurrencyPolicy,
+	}, nil
+}
+
+func parseJobValue(labels map[string]string, key string) (*int32, error) {
+	value := util.FilterBlank(util.GetOptional(labels, "k8ify."+key))
+	if value == nil {
+		return nil, nil
+	}
+	number, err := strconv.ParseInt(*value, 10, 32)
+	if err != nil || number < 0 {
+		return nil, fmt.Errorf("k8ify.%s must be a non-negative number, got %q", key, *value)
+	}
+	return util.GetPointer(int32(number)), nil
+}
+
+// BackupConfig holds the settings of the K8up Schedule backing up a volume
+type BackupConfig struct {
+	Schedule  string
+	KeepDaily int32
+}
+
+// BackupConfigPointer Parses the config values for backups via K8up. Returns nil if the volume is not backed up.
+func BackupConfigPointer(labels map[string]string) (*BackupConfig, error) {
+	if !util.GetBoolean(labels, "k8ify.backup") {
+		return nil, nil
+	}
+	config := BackupConfig{
+		Schedule:  "@daily-random",
+		KeepDaily: 7,
+	}
+	if schedule := util.FilterBlank(util.GetOptional(labels, "k8ify.backup.schedule")); schedule != nil {
+		config.Schedule = *schedule
+	}
+	if keepDaily := util.FilterBlank(util.GetOptional(labels, "k8ify.backup.keepDaily")); keepDaily != nil {
+		number, err := strconv.ParseInt(*keepDaily, 10, 32)
+		if err != nil || number < 1 {
+			return nil, fmt.Errorf("k8ify.backup.keepDaily must be a positive number, got %q", *keepDaily)
+		}
+		config.KeepDaily = int32(number)
+	}
+	return &config, nil
+}
+
+// CloneConfig describes where the data of a new volume is cloned from
+type CloneConfig struct {
+	Ref string
+	// Kind is either "PersistentVolumeClaim" or "VolumeSnapshot"
+	Kind string
+}
+
+// CloneConfigPointer Parses the config values for cloning volumes from another ref. Returns nil if the volume is not
+// cloned.
+func CloneConfigPointer(labels map[string]string) (*CloneConfig, error) {
+	ref := util.FilterBlank(util.GetOptional(labels, "k8ify.cloneFromRef"))
+	kind := util.FilterBlank(util.GetOptional(labels, "k8ify.cloneFromRef.kind"))
+	if ref == nil {
+		if kind != nil {
+			return nil, fmt.Errorf("k8ify.cloneFromRef.kind requires k8ify.cloneFromRef")
+		}
+		return nil, nil
+	}
+	if kind == nil {
+		kind = util.GetPointer("PersistentVolumeClaim")
+	}
+	if *kind != "PersistentVolumeClaim" && *kind != "VolumeSnapshot" {
+		return nil, fmt.Errorf("k8ify.cloneFromRef.kind must be PersistentVolumeClaim or VolumeSnapshot, got %q", *kind)
+	}
+	return &CloneConfig{
+		Ref:  *ref,
+		Kind: *kind,
+	}, nil
+}
+
 // ServiceMonitorConfig An intermediate struct that makes it easier to access all needed config values
 // in one place for the ServiceMonitor.
 // We did not use prometheus.ServiceMonitor directly, because then the name would be: serviceMonitor.Endpoints[0].name
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	composeTypes "github.com/compose-spec/compose-go/v2/types"
 	assertions "github.com/stretchr/testify/assert"
 	"github.com/vshn/k8ify/pkg/util"
 )
@@ -234,6 +235,212 @@ func TestServiceMonitorTlsConfig(t *testing.T) {
 	}
 }
 
+func TestAutoscaleConfig(t *testing.T) {
+	assert := assertions.New(t)
+	type LabelMap map[string]string
+
+	cases := []TestCase[LabelMap, *AutoscaleConfig, error]{
+		{
+			name:          "AutoscaleConfig_nothing_set",
+			input:         LabelMap{},
+			expectedValue: nil,
+		},
+		{
+			name:          "AutoscaleConfig_max_missing",
+			input:         LabelMap{"k8ify.autoscale.min": "2"},
+			expectedValue: nil,
+			expectedError: errors.New("k8ify.autoscale.max is required when autoscaling is configured"),
+		},
+		{
+			name:          "AutoscaleConfig_defaults",
+			input:         LabelMap{"k8ify.autoscale.max": "5"},
+			expectedValue: &AutoscaleConfig{Min: 1, Max: 5, Cpu: util.GetPointer(int32(80))},
+		},
+		{
+			name: "AutoscaleConfig_values_set",
+			input: LabelMap{
+				"k8ify.autoscale.min":    "2",
+				"k8ify.autoscale.max":    "10",
+				"k8ify.autoscale.cpu":    "70%",
+				"k8ify.autoscale.memory": "90",
+			},
+			expectedValue: &AutoscaleConfig{Min: 2, Max: 10, Cpu: util.GetPointer(int32(70)), Memory: util.GetPointer(int32(90))},
+		},
+		{
+			name: "AutoscaleConfig_memory_only",
+			input: LabelMap{
+				"k8ify.autoscale.max":    "3",
+				"k8ify.autoscale.memory": "75",
+			},
+			expectedValue: &AutoscaleConfig{Min: 1, Max: 3, Memory: util.GetPointer(int32(75))},
+		},
+		{
+			name: "AutoscaleConfig_min_exceeds_max",
+			input: LabelMap{
+				"k8ify.autoscale.min": "4",
+				"k8ify.autoscale.max": "3",
+			},
+			expectedValue: nil,
+			expectedError: errors.New("k8ify.autoscale.min (4) must be at least 1 and must not exceed k8ify.autoscale.max (3)"),
+		},
+		{
+			name:          "AutoscaleConfig_invalid_number",
+			input:         LabelMap{"k8ify.autoscale.max": "many"},
+			expectedValue: nil,
+			expectedError: errors.New("k8ify.autoscale.max must be a positive number, got \"many\""),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := AutoscaleConfigPointer(tc.input)
+
+			assert.Equal(tc.expectedValue, actual, "AutoscaleConfigPointer(%v) should return value %v", tc.input, tc.expectedValue)
+			assert.Equal(tc.expectedError, err, "AutoscaleConfigPointer(%v) should return err %v", tc.input, tc.expectedError)
+		})
+	}
+}
+
+func TestJobConfig(t *testing.T) {
+	assert := assertions.New(t)
+	type LabelMap map[string]string
+
+	cases := []TestCase[LabelMap, *JobConfig, error]{
+		{
+			name:          "JobConfig_nothing_set",
+			input:         LabelMap{},
+			expectedValue: nil,
+		},
+		{
+			name:          "JobConfig_job",
+			input:         LabelMap{"k8ify.kind": "Job", "k8ify.backoffLimit": "2", "k8ify.ttlSecondsAfterFinished": "0"},
+			expectedValue: &JobConfig{Kind: "Job", BackoffLimit: util.GetPointer(int32(2)), TTLSecondsAfterFinished: util.GetPointer(int32(0))},
+		},
+		{
+			name:          "JobConfig_cronjob",
+			input:         LabelMap{"k8ify.kind": "CronJob", "k8ify.schedule": "0 3 * * *", "k8ify.concurrencyPolicy": "Forbid"},
+			expectedValue: &JobConfig{Kind: "CronJob", Schedule: util.GetPointer("0 3 * * *"), ConcurrencyPolicy: util.GetPointer("Forbid")},
+		},
+		{
+			name:          "JobConfig_cronjob_without_schedule",
+			input:         LabelMap{"k8ify.kind": "CronJob"},
+			expectedValue: nil,
+			expectedError: errors.New("k8ify.schedule is required for a CronJob"),
+		},
+		{
+			name:          "JobConfig_schedule_without_kind",
+			input:         LabelMap{"k8ify.schedule": "0 3 * * *"},
+			expectedValue: nil,
+			expectedError: errors.New("k8ify.schedule requires k8ify.kind to be CronJob"),
+		},
+		{
+			name:          "JobConfig_unknown_kind",
+			input:         LabelMap{"k8ify.kind": "DaemonSet"},
+			expectedValue: nil,
+			expectedError: errors.New("k8ify.kind must be Job or CronJob, got \"DaemonSet\""),
+		},
+		{
+			name:          "JobConfig_invalid_backoff_limit",
+			input:         LabelMap{"k8ify.kind": "Job", "k8ify.backoffLimit": "-1"},
+			expectedValue: nil,
+			expectedError: errors.New("k8ify.backoffLimit must be a non-negative number, got \"-1\""),
+		},
+		{
+			name:          "JobConfig_invalid_concurrency_policy",
+			input:         LabelMap{"k8ify.kind": "CronJob", "k8ify.schedule": "@daily", "k8ify.concurrencyPolicy": "Never"},
+			expectedValue: nil,
+			expectedError: errors.New("k8ify.concurrencyPolicy must be Allow, Forbid or Replace, got \"Never\""),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := JobConfigPointer(tc.input)
+
+			assert.Equal(tc.expectedValue, actual, "JobConfigPointer(%v) should return value %v", tc.input, tc.expectedValue)
+			assert.Equal(tc.expectedError, err, "JobConfigPointer(%v) should return err %v", tc.input, tc.expectedError)
+		})
+	}
+}
+
+func TestBackupConfig(t *testing.T) {
+	assert := assertions.New(t)
+	type LabelMap map[string]string
+
+	cases := []TestCase[LabelMap, *BackupConfig, error]{
+		{
+			name:          "BackupConfig_nothing_set",
+			input:         LabelMap{"k8ify.backup.schedule": "@hourly"},
+			expectedValue: nil,
+		},
+		{
+			name:          "BackupConfig_defaults",
+			input:         LabelMap{"k8ify.backup": "true"},
+			expectedValue: &BackupConfig{Schedule: "@daily-random", KeepDaily: 7},
+		},
+		{
+			name:          "BackupConfig_all_set",
+			input:         LabelMap{"k8ify.backup": "true", "k8ify.backup.schedule": "0 3 * * *", "k8ify.backup.keepDaily": "14"},
+			expectedValue: &BackupConfig{Schedule: "0 3 * * *", KeepDaily: 14},
+		},
+		{
+			name:          "BackupConfig_invalid_keep_daily",
+			input:         LabelMap{"k8ify.backup": "true", "k8ify.backup.keepDaily": "0"},
+			expectedValue: nil,
+			expectedError: errors.New("k8ify.backup.keepDaily must be a positive number, got \"0\""),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := BackupConfigPointer(tc.input)
+
+			assert.Equal(tc.expectedValue, actual, "BackupConfigPointer(%v) should return value %v", tc.input, tc.expectedValue)
+			assert.Equal(tc.expectedError, err, "BackupConfigPointer(%v) should return err %v", tc.input, tc.expectedError)
+		})
+	}
+}
+
+func TestCloneConfig(t *testing.T) {
+	assert := assertions.New(t)
+	type LabelMap map[string]string
+
+	cases := []TestCase[LabelMap, *CloneConfig, error]{
+		{
+			name:          "CloneConfig_nothing_set",
+			input:         LabelMap{},
+			expectedValue: nil,
+		},
+		{
+			name:          "CloneConfig_pvc",
+			input:         LabelMap{"k8ify.cloneFromRef": "main"},
+			expectedValue: &CloneConfig{Ref: "main", Kind: "PersistentVolumeClaim"},
+		},
+		{
+			name:          "CloneConfig_snapshot",
+			input:         LabelMap{"k8ify.cloneFromRef": "main", "k8ify.cloneFromRef.kind": "VolumeSnapshot"},
+			expectedValue: &CloneConfig{Ref: "main", Kind: "VolumeSnapshot"},
+		},
+		{
+			name:          "CloneConfig_kind_without_ref",
+			input:         LabelMap{"k8ify.cloneFromRef.kind": "VolumeSnapshot"},
+			expectedValue: nil,
+			expectedError: errors.New("k8ify.cloneFromRef.kind requires k8ify.cloneFromRef"),
+		},
+		{
+			name:          "CloneConfig_unknown_kind",
+			input:         LabelMap{"k8ify.cloneFromRef": "main", "k8ify.cloneFromRef.kind": "Volume"},
+			expectedValue: nil,
+			expectedError: errors.New("k8ify.cloneFromRef.kind must be PersistentVolumeClaim or VolumeSnapshot, got \"Volume\""),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := CloneConfigPointer(tc.input)
+
+			assert.Equal(tc.expectedValue, actual, "CloneConfigPointer(%v) should return value %v", tc.input, tc.expectedValue)
+			assert.Equal(tc.expectedError, err, "CloneConfigPointer(%v) should return err %v", tc.input, tc.expectedError)
+		})
+	}
+}
+
 type TestCase[InParam any, OutParam any, ErrorType any] struct {
 	name          string
 	input         InParam
@@ -255,3 +462,150 @@ var (
 	monitorTlsMinVersion  = "TLS10"
 	monitorUsername       = "myuser"
 )
+
+func TestGetPorts(t *testing.T) {
+	assert := assertions.New(t)
+
+	service := NewService("dns", composeTypes.ServiceConfig{
+		Ports: []composeTypes.ServicePortConfig{
+			{Target: 1053, Published: "53", Protocol: "udp"},
+			{Target: 1053, Published: "53"},
+			{Target: 8080, Published: "80", Mode: "host", HostIP: "127.0.0.1", Protocol: "tcp"},
+		},
+	})
+	assert.Equal([]PublishedPort{
+		{ServicePort: 53, ContainerPort: 1053, Protocol: "UDP"},
+		{ServicePort: 53, ContainerPort: 1053, Protocol: "TCP"},
+		{ServicePort: 80, ContainerPort: 8080, Protocol: "TCP", HostMode: true, HostIP: "127.0.0.1"},
+	}, service.GetPorts())
+
+	service = NewService("ftp", composeTypes.ServiceConfig{
+		Ports: []composeTypes.ServicePortConfig{
+			{Target: 21000, Published: "30000-30002"},
+		},
+	})
+	assert.Equal([]PublishedPort{
+		{ServicePort: 30000, ContainerPort: 21000, Protocol: "TCP"},
+	}, service.GetPorts())
+	assert.NoError(service.CheckPortRanges(1))
+
+	service = NewService("ftp", composeTypes.ServiceConfig{
+		Ports: []composeTypes.ServicePortConfig{
+			{Target: 30000, Published: "30000"},
+			{Target: 30001, Published: "30001"},
+			{Target: 30002, Published: "30002"},
+		},
+	})
+	assert.NoError(service.CheckPortRanges(3))
+	assert.EqualError(service.CheckPortRanges(2), "3 ports are published or exposed (with all port ranges expanded), the maximum is 2. Use smaller port ranges or adjust 'x-targetCfg.maxPorts'")
+
+	service = NewService("ftp", composeTypes.ServiceConfig{
+		Ports: []composeTypes.ServicePortConfig{
+			{Target: 21, Published: "30002-30000"},
+		},
+	})
+	assert.EqualError(service.CheckPortRanges(100), "invalid published port range '30002-30000'")
+}
+
+func TestGetExposedPorts(t *testing.T) {
+	assert := assertions.New(t)
+
+	service := NewService("backend", composeTypes.ServiceConfig{
+		Ports: []composeTypes.ServicePortConfig{
+			{Target: 8080, Published: "80"},
+		},
+		Expose: composeTypes.StringOrNumberList{"8080", "9090-9091", "53/udp"},
+	})
+	assert.Equal([]PublishedPort{
+		{ServicePort: 9090, ContainerPort: 9090, Protocol: "TCP"},
+		{ServicePort: 9091, ContainerPort: 9091, Protocol: "TCP"},
+		{ServicePort: 53, ContainerPort: 53, Protocol: "UDP"},
+	}, service.GetExposedPorts())
+	assert.EqualError(service.CheckPortRanges(3), "4 ports are published or exposed (with all port ranges expanded), the maximum is 3. Use smaller port ranges or adjust 'x-targetCfg.maxPorts'")
+}
+
+func TestGetIngressConfigs(t *testing.T) {
+	assert := assertions.New(t)
+
+	service := NewService("web", composeTypes.ServiceConfig{
+		Ports: []composeTypes.ServicePortConfig{
+			{Target: 53, Published: "53", Protocol: "udp"},
+			{Target: 8080, Published: "80"},
+			{Target: 9090, Published: "9090"},
+			{Target: 9091, Published: "9091"},
+		},
+		Labels: composeTypes.Labels{
+			"k8ify.expose":                                    "example.com, www.example.com",
+			"k8ify.expose.9090":                               "api.example.com",
+			"k8ify.expose.9090.path":                          "/v1",
+			"k8ify.expose.9090.pathType":                      "Exact",
+			"k8ify.expose.9090.tlsTermination":                "reencrypt",
+			"k8ify.expose.9090.insecureEdgeTerminationPolicy": "None",
+		},
+	})
+	ingressConfigs, err := service.GetIngressConfigs()
+	assert.NoError(err)
+	assert.Equal([]IngressConfig{
+		{Port: 80, Hosts: []string{"example.com", "www.example.com"}, Path: "/", PathType: "Prefix", TLSTermination: "edge", InsecureEdgeTerminationPolicy: "Redirect"},
+		{Port: 9090, Hosts: []string{"api.example.com"}, Path: "/v1", PathType: "Exact", TLSTermination: "reencrypt", InsecureEdgeTerminationPolicy: "None"},
+	}, ingressConfigs)
+
+	service = NewService("web", composeTypes.ServiceConfig{
+		Ports: []composeTypes.ServicePortConfig{
+			{Target: 8080, Published: "80"},
+		},
+		Labels: composeTypes.Labels{
+			"k8ify.expose":      "example.com",
+			"k8ify.expose.path": "v1",
+		},
+	})
+	_, err = service.GetIngressConfigs()
+	assert.EqualError(err, "k8ify.expose.path must start with '/', got \"v1\"")
+
+	service = NewService("web", composeTypes.ServiceConfig{
+		Ports: []composeTypes.ServicePortConfig{
+			{Target: 8080, Published: "80"},
+		},
+		Labels: composeTypes.Labels{
+			"k8ify.expose":                "example.com",
+			"k8ify.expose.tlsTermination": "Edge",
+		},
+	})
+	_, err = service.GetIngressConfigs()
+	assert.EqualError(err, "k8ify.expose.tlsTermination must be edge, reencrypt or passthrough, got \"Edge\"")
+
+	service = NewService("web", composeTypes.ServiceConfig{
+		Ports: []composeTypes.ServicePortConfig{
+			{Target: 8080, Published: "80"},
+		},
+		Labels: composeTypes.Labels{
+			"k8ify.expose":                 "example.com",
+			"k8ify.expose.maxBodySize":     "10m",
+			"k8ify.expose.allowFrom":       "10.0.0.0/8, 192.0.2.1",
+			"k8ify.expose.basicAuth":       "alice:$apr1$hash, bob:$apr1$hash",
+			"k8ify.expose.timeout":         "300",
+			"k8ify.expose.redirectToHTTPS": "true",
+		},
+	})
+	ingressConfigs, err = service.GetIngressConfigs()
+	assert.NoError(err)
+	assert.Equal(IngressFeatures{
+		MaxBodySize:     10 * 1024 * 1024,
+		AllowFrom:       []string{"10.0.0.0/8", "192.0.2.1"},
+		BasicAuth:       []string{"alice:$apr1$hash", "bob:$apr1$hash"},
+		Timeout:         300,
+		RedirectToHTTPS: util.GetPointer(true),
+	}, ingressConfigs[0].Features)
+
+	service = NewService("web", composeTypes.ServiceConfig{
+		Ports: []composeTypes.ServicePortConfig{
+			{Target: 8080, Published: "80"},
+		},
+		Labels: composeTypes.Labels{
+			"k8ify.expose":           "example.com",
+			"k8ify.expose.allowFrom": "10.0.0.0/33",
+		},
+	})
+	_, err = service.GetIngressConfigs()
+	assert.EqualError(err, "k8ify.expose.allowFrom must be a comma-separated list of IP addresses and CIDRs, got \"10.0.0.0/33\"")
+}
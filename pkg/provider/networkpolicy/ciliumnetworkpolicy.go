@@ -19,21 +19,25 @@ func CreateNetworkPoliciesForExposedPorts(targetCfg ir.TargetCfg, refSlug string
 	for _, servicePort := range servicePorts {
 		serviceType := util.ServiceType(workload.Labels(), servicePort.Port)
 		if serviceType == v1.ServiceTypeLoadBalancer {
-			networkPolicy := createCiliumNetworkPolicy(refSlug, workload, labels, servicePort.Port)
+			networkPolicy := createCiliumNetworkPolicy(refSlug, workload, labels, servicePort)
 			networkPolicies = append(networkPolicies, networkPolicy)
 		}
 	}
 	return networkPolicies
 }
 
-func createCiliumNetworkPolicy(refSlug string, workload *ir.ParentService, labels map[string]string, port int32) unstructured.Unstructured {
-	portNrAsString := fmt.Sprint(port)
+func createCiliumNetworkPolicy(refSlug string, workload *ir.ParentService, labels map[string]string, servicePort v1.ServicePort) unstructured.Unstructured {
+	portNrAsString := fmt.Sprint(servicePort.Port)
+	protocol := v1.ProtocolTCP
+	if servicePort.Protocol != "" {
+		protocol = servicePort.Protocol
+	}
 	return unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": "cilium.io/v2",
 			"kind":       "CiliumNetworkPolicy",
 			"metadata": map[string]interface{}{
-				"name":   workload.Name + refSlug + "-" + portNrAsString + "-allow-from-world",
+				"name":   workload.Name + refSlug + "-" + servicePort.Name + "-allow-from-world",
 				"labels": labels,
 			},
 			"spec": map[string]interface{}{
@@ -50,7 +54,7 @@ func createCiliumNetworkPolicy(refSlug string, workload *ir.ParentService, label
 								"ports": []map[string]interface{}{
 									{
 										"port":     portNrAsString,
-										"protocol": "ANY",
+										"protocol": string(protocol),
 									},
 								},
 							},
@@ -0,0 +1,275 @@
+package networkpolicy
+
+import (
+	"sort"
+
+	"github.com/vshn/k8ify/pkg/ir"
+	"github.com/vshn/k8ify/pkg/util"
+	core "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// CreateNetworkPolicies creates a default-deny NetworkPolicy for the ref of the workload and a NetworkPolicy allowing
+// the traffic the workload expects: From all services sharing a Compose network with it, from the ingress controller to
+// ports exposed via Ingress, from Prometheus to ports scraped via ServiceMonitor and from the world to plain exposed
+// ports. The default-deny NetworkPolicy is the same for all
+// workloads of a ref, Objects.Append() takes care of deduplication.
+func CreateNetworkPolicies(
+	targetCfg ir.TargetCfg,
+	refSlug string,
+	workload *ir.ParentService,
+	labels map[string]string,
+	projectServices map[string]*ir.ParentService,
+	services []core.Service,
+	ingresses []networking.Ingress,
+	routes []unstructured.Unstructured,
+	serviceMonitors []unstructured.Unstructured,
+) []networking.NetworkPolicy {
+	if !targetCfg.NetworkPolicies() {
+		return []networking.NetworkPolicy{}
+	}
+
+	networkPolicy := newNetworkPolicy(workload.Name+refSlug+"-allow", labels)
+	networkPolicy.Annotations = util.Annotations(workload.Labels(), "NetworkPolicy")
+	networkPolicy.Spec.PodSelector = metav1.LabelSelector{MatchLabels: labels}
+
+	peers := []networking.NetworkPolicyPeer{}
+	for _, peer := range networkPeers(workload, projectServices) {
+		peerLabels := map[string]string{"k8ify.service": peer.Name}
+		// singletons are shared by all refs, hence they accept traffic from every ref
+		if refSlug, ok := labels["k8ify.ref-slug"]; ok && !util.IsSingleton(peer.Labels()) {
+			peerLabels["k8ify.ref-slug"] = refSlug
+		}
+		peers = append(peers, networking.NetworkPolicyPeer{
+			PodSelector: &metav1.LabelSelector{MatchLabels: peerLabels},
+		})
+	}
+	networkPolicy.Spec.Ingress = append(networkPolicy.Spec.Ingress, networking.NetworkPolicyIngressRule{From: peers})
+
+	ports := ingressPorts(services, ingresses)
+	for _, port := range routePorts(services, routes) {
+		ports = appendPort(ports, port)
+	}
+	if len(ports) > 0 {
+		namespaceSelector := &metav1.LabelSelector{}
+		namespace := targetCfg.NetworkPolicyIngressNamespace()
+		if namespace == "" && targetCfg.ExposeScheme() == ir.ExposeSchemeGatewayAPI {
+			namespace = targetCfg.GatewayNamespace()
+		}
+		if namespace != "" {
+			namespaceSelector.MatchLabels = map[string]string{"kubernetes.io/metadata.name": namespace}
+		} else if targetCfg.ExposeScheme() == ir.ExposeSchemeOpenShiftRoute {
+			// the OpenShift router may run in the host network, this label also covers that case
+			namespaceSelector.MatchLabels = map[string]string{"policy-group.network.openshift.io/ingress": ""}
+		}
+		networkPolicy.Spec.Ingress = append(networkPolicy.Spec.Ingress, networking.NetworkPolicyIngressRule{
+			Ports: ports,
+			From:  []networking.NetworkPolicyPeer{{NamespaceSelector: namespaceSelector}},
+		})
+	}
+
+	if ports := serviceMonitorPorts(services, serviceMonitors); len(ports) > 0 {
+		namespaceSelector := &metav1.LabelSelector{}
+		if namespace := targetCfg.NetworkPolicyMonitoringNamespace(); namespace != "" {
+			namespaceSelector.MatchLabels = map[string]string{"kubernetes.io/metadata.name": namespace}
+		}
+		networkPolicy.Spec.Ingress = append(networkPolicy.Spec.Ingress, networking.NetworkPolicyIngressRule{
+			Ports: ports,
+			From:  []networking.NetworkPolicyPeer{{NamespaceSelector: namespaceSelector}},
+		})
+	}
+
+	if ports := plainExposedPorts(services); len(ports) > 0 {
+		networkPolicy.Spec.Ingress = append(networkPolicy.Spec.Ingress, networking.NetworkPolicyIngressRule{
+			Ports: ports,
+			From: []networking.NetworkPolicyPeer{
+				{IPBlock: &networking.IPBlock{CIDR: "0.0.0.0/0"}},
+				{IPBlock: &networking.IPBlock{CIDR: "::/0"}},
+			},
+		})
+	}
+
+	return []networking.NetworkPolicy{createDefaultDenyNetworkPolicy(labels), networkPolicy}
+}
+
+// createDefaultDenyNetworkPolicy denies all incoming traffic to the pods of a ref. Singletons don't belong to a ref,
+// they get a default-deny NetworkPolicy of their own.
+func createDefaultDenyNetworkPolicy(labels map[string]string) networking.NetworkPolicy {
+	if refSlug, ok := labels["k8ify.ref-slug"]; ok {
+		refLabels := map[string]string{"k8ify.ref-slug": refSlug}
+		networkPolicy := newNetworkPolicy("default-deny-"+refSlug, refLabels)
+		networkPolicy.Spec.PodSelector = metav1.LabelSelector{MatchLabels: refLabels}
+		return networkPolicy
+	}
+
+	networkPolicy := newNetworkPolicy("default-deny-singletons", nil)
+	networkPolicy.Spec.PodSelector = metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "k8ify.service", Operator: metav1.LabelSelectorOpExists},
+			{Key: "k8ify.ref-slug", Operator: metav1.LabelSelectorOpDoesNotExist},
+		},
+	}
+	return networkPolicy
+}
+
+func newNetworkPolicy(name string, labels map[string]string) networking.NetworkPolicy {
+	networkPolicy := networking.NetworkPolicy{}
+	networkPolicy.APIVersion = "networking.k8s.io/v1"
+	networkPolicy.Kind = "NetworkPolicy"
+	networkPolicy.Name = name
+	networkPolicy.Labels = labels
+	networkPolicy.Spec.PolicyTypes = []networking.PolicyType{networking.PolicyTypeIngress}
+	return networkPolicy
+}
+
+// networkPeers returns all services (including the workload itself) sharing at least one Compose network with the
+// workload or one of its parts, sorted by name. Services without `networks` are attached to the "default" network by
+// Compose.
+func networkPeers(workload *ir.ParentService, projectServices map[string]*ir.ParentService) []*ir.ParentService {
+	workloadNetworks := serviceNetworks(workload)
+	peers := []*ir.ParentService{}
+	for _, service := range projectServices {
+		for network := range serviceNetworks(service) {
+			if workloadNetworks[network] {
+				peers = append(peers, service)
+				break
+			}
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Name < peers[j].Name
+	})
+	return peers
+}
+
+func serviceNetworks(workload *ir.ParentService) map[string]bool {
+	networks := make(map[string]bool)
+	for _, service := range append([]*ir.Service{&workload.Service}, workload.GetParts()...) {
+		for network := range service.AsCompose().Networks {
+			networks[network] = true
+		}
+	}
+	return networks
+}
+
+// ingressPorts returns the container ports the Ingresses forward traffic to
+func ingressPorts(services []core.Service, ingresses []networking.Ingress) []networking.NetworkPolicyPort {
+	ports := []networking.NetworkPolicyPort{}
+	for _, ingress := range ingresses {
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				backend := path.Backend.Service
+				if backend == nil {
+					continue
+				}
+				for _, service := range services {
+					if service.Name != backend.Name {
+						continue
+					}
+					for _, servicePort := range service.Spec.Ports {
+						if servicePort.Port == backend.Port.Number && servicePort.Protocol == "" {
+							ports = appendPort(ports, servicePort)
+						}
+					}
+				}
+			}
+		}
+	}
+	return ports
+}
+
+// routePorts returns the Service ports the Gateway API or OpenShift routes forward traffic to
+func routePorts(services []core.Service, routes []unstructured.Unstructured) []core.ServicePort {
+	ports := []core.ServicePort{}
+	for _, route := range routes {
+		if route.GetKind() == "Route" {
+			name, _, _ := unstructured.NestedString(route.Object, "spec", "to", "name")
+			targetPort, _, _ := unstructured.NestedString(route.Object, "spec", "port", "targetPort")
+			for _, service := range services {
+				if service.Name != name {
+					continue
+				}
+				for _, servicePort := range service.Spec.Ports {
+					if servicePort.Name == targetPort {
+						ports = append(ports, servicePort)
+					}
+				}
+			}
+			continue
+		}
+		rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+		for _, rule := range rules {
+			backendRefs, _, _ := unstructured.NestedSlice(rule.(map[string]interface{}), "backendRefs")
+			for _, backendRef := range backendRefs {
+				name, _, _ := unstructured.NestedString(backendRef.(map[string]interface{}), "name")
+				port, _, _ := unstructured.NestedInt64(backendRef.(map[string]interface{}), "port")
+				for _, service := range services {
+					if service.Name != name {
+						continue
+					}
+					for _, servicePort := range service.Spec.Ports {
+						if int64(servicePort.Port) == port {
+							ports = append(ports, servicePort)
+						}
+					}
+				}
+			}
+		}
+	}
+	return ports
+}
+
+// serviceMonitorPorts returns the container ports Prometheus scrapes via the endpoints of the ServiceMonitors
+func serviceMonitorPorts(services []core.Service, serviceMonitors []unstructured.Unstructured) []networking.NetworkPolicyPort {
+	ports := []networking.NetworkPolicyPort{}
+	for _, serviceMonitor := range serviceMonitors {
+		endpoints, _, _ := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+		for _, endpoint := range endpoints {
+			name, _, _ := unstructured.NestedString(endpoint.(map[string]interface{}), "port")
+			for _, service := range services {
+				for _, servicePort := range service.Spec.Ports {
+					if servicePort.Name == name {
+						ports = appendPort(ports, servicePort)
+					}
+				}
+			}
+		}
+	}
+	return ports
+}
+
+// plainExposedPorts returns the container ports exposed via Services of type LoadBalancer or NodePort
+func plainExposedPorts(services []core.Service) []networking.NetworkPolicyPort {
+	ports := []networking.NetworkPolicyPort{}
+	for _, service := range services {
+		if service.Spec.Type != core.ServiceTypeLoadBalancer && service.Spec.Type != core.ServiceTypeNodePort {
+			continue
+		}
+		for _, servicePort := range service.Spec.Ports {
+			ports = appendPort(ports, servicePort)
+		}
+	}
+	return ports
+}
+
+// appendPort adds the target port of a Service port, NetworkPolicies apply to the ports of the pods
+func appendPort(ports []networking.NetworkPolicyPort, servicePort core.ServicePort) []networking.NetworkPolicyPort {
+	port := intstr.FromInt32(servicePort.TargetPort.IntVal)
+	for _, p := range ports {
+		if *p.Port == port && (p.Protocol == nil && servicePort.Protocol == "" || p.Protocol != nil && *p.Protocol == servicePort.Protocol) {
+			return ports
+		}
+	}
+	networkPolicyPort := networking.NetworkPolicyPort{Port: &port}
+	if servicePort.Protocol != "" {
+		protocol := servicePort.Protocol
+		networkPolicyPort.Protocol = &protocol
+	}
+	return append(ports, networkPolicyPort)
+}
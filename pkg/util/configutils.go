@@ -163,9 +163,33 @@ func ServiceType(labels map[string]string, port int32) core.ServiceType {
 			return core.ServiceTypeNodePort
 		}
 	}
+	if _, ok := subConfig["gatewayListener"]; ok {
+		// the Gateway receives the traffic and forwards it to the Service
+		return core.ServiceTypeClusterIP
+	}
 	return core.ServiceTypeLoadBalancer
 }
 
+// ServiceGatewayListener returns the name of the Gateway listener a plain exposed port is attached to, or "" if the
+// port is not exposed via Gateway API
+func ServiceGatewayListener(labels map[string]string, port int32) string {
+	subConfig := SubConfig(labels, fmt.Sprintf("k8ify.exposePlain.%d", port), "")
+	return subConfig["gatewayListener"]
+}
+
+// ServiceTLSHosts returns the hosts the certificate of a plain exposed port is issued for, or nil if the port doesn't
+// need a certificate
+func ServiceTLSHosts(labels map[string]string, port int32) []string {
+	subConfig := SubConfig(labels, fmt.Sprintf("k8ify.exposePlain.%d", port), "")
+	var hosts []string
+	for _, host := range strings.Split(subConfig["tlsHost"], ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
 func ServiceExternalTrafficPolicy(labels map[string]string, port int32) core.ServiceExternalTrafficPolicy {
 	subConfig := SubConfig(labels, fmt.Sprintf("k8ify.exposePlain.%d", port), "")
 	if len(subConfig) == 0 {
@@ -180,6 +204,10 @@ func ServiceExternalTrafficPolicy(labels map[string]string, port int32) core.Ser
 			return core.ServiceExternalTrafficPolicyLocal
 		}
 	}
+	if _, ok := subConfig["gatewayListener"]; ok {
+		// ClusterIP Services have no external traffic
+		return ""
+	}
 	return core.ServiceExternalTrafficPolicyLocal
 }
 
@@ -77,3 +77,15 @@ func TestIsTruthy(t *testing.T) {
 	assert.False(util.IsTruthy("NO"))
 	assert.False(util.IsTruthy("0"))
 }
+
+func TestServiceTLSHosts(t *testing.T) {
+	labels := map[string]string{
+		"k8ify.exposePlain.8883":         "true",
+		"k8ify.exposePlain.8883.tlsHost": "mqtt.example.com, broker.example.com",
+		"k8ify.exposePlain.1883":         "true",
+	}
+
+	assert.Equal(t, []string{"mqtt.example.com", "broker.example.com"}, util.ServiceTLSHosts(labels, 8883))
+	assert.Nil(t, util.ServiceTLSHosts(labels, 1883))
+	assert.Nil(t, util.ServiceTLSHosts(labels, 5432))
+}